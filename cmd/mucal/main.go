@@ -18,7 +18,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -28,13 +28,21 @@ import (
 	"github.com/mano/mucal"
 	"github.com/mano/mucal/internal/api"
 	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/logging"
 	"github.com/mano/mucal/internal/version"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "share" {
+		os.Exit(runShare(os.Args[2:]))
+	}
+
 	// Parse command line flags
 	configPath := flag.String("config", "config.yaml", "Path to configuration file")
 	port := flag.Int("port", 8080, "Port to listen on")
+	logLevel := flag.String("log-level", "", "Log level: debug, info, warn or error (overrides the configuration)")
+	logFormat := flag.String("log-format", "", "Log format: text or json (overrides the configuration)")
 	flag.Parse()
 
 	// If a positional argument is provided, use it as config path
@@ -42,20 +50,43 @@ func main() {
 		*configPath = flag.Args()[0]
 	}
 
-	log.Printf("μCal version: %s", version.Version)
+	// Log as set by the flags until the configuration is loaded
+	if err := setupLogging(&config.Log{Level: *logLevel, Format: *logFormat}); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid logging flags: %v\n", err)
+		os.Exit(2)
+	}
+
+	slog.Info("starting μCal", "version", version.Version)
 
 	// Load configuration
-	log.Printf("Loading configuration from: %s", *configPath)
+	slog.Info("loading configuration", "path", *configPath)
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("failed to load configuration", err)
+	}
+	// The flags override the configuration
+	logConfig := func(cfg *config.Config) *config.Log {
+		l := cfg.Log
+		if *logLevel != "" {
+			l.Level = *logLevel
+		}
+		if *logFormat != "" {
+			l.Format = *logFormat
+		}
+		return &l
+	}
+	if err := setupLogging(logConfig(cfg)); err != nil {
+		fatal("invalid log configuration", err)
 	}
-	log.Printf("Loaded %d calendar(s)", len(cfg.Calendars))
+	slog.Info("loaded configuration", "calendars", len(cfg.Calendars), "accounts", len(cfg.Accounts))
 
 	// Create API handler
 	handler, err := api.NewHandler(cfg)
 	if err != nil {
-		log.Fatalf("Failed to create API handler: %v", err)
+		fatal("failed to create API handler", err)
+	}
+	if len(cfg.Accounts) > 0 {
+		slog.Info("discovered calendars", "calendars", len(cfg.Calendars))
 	}
 
 	// Setup routes
@@ -67,12 +98,17 @@ func main() {
 	fileServer := http.FileServer(webFS)
 	mux.Handle("/", fileServer)
 
-	// Wrap with middleware
-	wrappedHandler := api.RecoveryMiddleware(
-		api.LoggingMiddleware(
-			api.CORSMiddleware(mux),
-		),
-	)
+	// Wrap with middleware. Cross-origin requests are only allowed when
+	// anyone may read the calendars.
+	var appHandler http.Handler = handler.AuthMiddleware(mux)
+	if cfg.Auth.GetMode() == config.AuthNone {
+		appHandler = api.CORSMiddleware(appHandler)
+	} else {
+		slog.Info("authentication enabled", "mode", cfg.Auth.GetMode())
+	}
+	wrappedHandler := handler.MetricsMiddleware(api.LoggingMiddleware(
+		api.RecoveryMiddleware(appHandler),
+	))
 
 	// Create HTTP server
 	addr := fmt.Sprintf(":%d", *port)
@@ -84,11 +120,28 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Event streams never end on their own, so close them when shutting down
+	server.RegisterOnShutdown(handler.CloseStreams)
+
+	// Reload the configuration on SIGHUP, and when it or a password file
+	// changes
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	go watchFiles(watchCtx, func() []string {
+		return append([]string{*configPath}, handler.Config().PasswordFiles()...)
+	}, func() {
+		if handler.Reload(*configPath) != nil {
+			return
+		}
+		if err := setupLogging(logConfig(handler.Config())); err != nil {
+			slog.Error("invalid log configuration", "error", err)
+		}
+	})
+
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting server on %s", addr)
+		slog.Info("starting server", "addr", addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+			fatal("server failed", err)
 		}
 	}()
 
@@ -97,7 +150,7 @@ func main() {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server")
 
 	// Create shutdown context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -105,8 +158,28 @@ func main() {
 
 	// Attempt graceful shutdown
 	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+		slog.Error("server shutdown failed", "error", err)
+	}
+
+	// Stop reloading and background calendar sync
+	stopWatching()
+	handler.Close()
+
+	slog.Info("server stopped")
+}
+
+// setupLogging makes a logger with the given configuration the default one
+func setupLogging(cfg *config.Log) error {
+	logger, err := logging.New(os.Stderr, cfg)
+	if err != nil {
+		return err
 	}
+	slog.SetDefault(logger)
+	return nil
+}
 
-	log.Println("Server stopped")
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,75 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// watchInterval is how often the configuration and password files are
+// checked for changes
+const watchInterval = 5 * time.Second
+
+// watchFiles calls reload on SIGHUP and whenever one of the files returned
+// by files changes, until ctx is done. The files are listed again after each
+// reload, as they may have changed with the configuration.
+func watchFiles(ctx context.Context, files func() []string, reload func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	stamps := fileStamps(files())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("reloading configuration on SIGHUP")
+		case <-ticker.C:
+			if maps.Equal(fileStamps(files()), stamps) {
+				continue
+			}
+			slog.Info("configuration changed, reloading")
+		}
+
+		reload()
+		stamps = fileStamps(files())
+	}
+}
+
+// fileStamps returns the modification time and size of each file, or its
+// error, so that changes can be detected
+func fileStamps(files []string) map[string]string {
+	stamps := make(map[string]string, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			stamps[file] = err.Error()
+			continue
+		}
+		stamps[file] = fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+	}
+	return stamps
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/share"
+)
+
+// shareUsage describes the share subcommand
+const shareUsage = `Usage:
+  mucal share create [-config file] -calendar name [-calendar name...] [-privacy level] [-expires duration] [-label text]
+  mucal share list [-config file]
+  mucal share revoke [-config file] id
+`
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runShare runs the share subcommand, managing the share links of the
+// configuration, and returns the exit code
+func runShare(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, shareUsage)
+		return 2
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("share "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, shareUsage) }
+	configPath := flags.String("config", "config.yaml", "Path to configuration file")
+
+	var calendars stringList
+	var privacy, label *string
+	var expires *time.Duration
+	if command == "create" {
+		flags.Var(&calendars, "calendar", "Calendar to share, as a glob pattern (repeatable)")
+		privacy = flags.String("privacy", config.PrivacyFull, "Privacy level: full, title-only or busy")
+		expires = flags.Duration("expires", 0, "Lifetime of the link, such as 720h (default: no expiry)")
+		label = flags.String("label", "", "Description of the link")
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+	if !cfg.Shares.IsEnabled() {
+		fmt.Fprintln(os.Stderr, "Share links are not enabled: set shares.state_file in the configuration")
+		return 1
+	}
+	store, err := share.Open(cfg.Shares.StateFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open share links: %v\n", err)
+		return 1
+	}
+
+	switch command {
+	case "create":
+		if flags.NArg() != 0 {
+			flags.Usage()
+			return 2
+		}
+		var expiry *time.Time
+		if *expires > 0 {
+			t := time.Now().Add(*expires).UTC().Truncate(time.Second)
+			expiry = &t
+		}
+		sh, token, err := store.Create(*label, calendars, *privacy, expiry)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create share link: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Created share %s\n", sh.ID)
+		fmt.Printf("Link:  /s/%s/\n", token)
+		fmt.Printf("Token: %s\n", token)
+
+	case "list":
+		if flags.NArg() != 0 {
+			flags.Usage()
+			return 2
+		}
+		shares, err := store.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list share links: %v\n", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tLABEL\tCALENDARS\tPRIVACY\tEXPIRES")
+		for _, sh := range shares {
+			expiry := "never"
+			if sh.Expires != nil {
+				expiry = sh.Expires.Format(time.RFC3339)
+				if sh.Expired(time.Now()) {
+					expiry += " (expired)"
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", sh.ID, sh.Label, strings.Join(sh.Calendars, ","), sh.Privacy, expiry)
+		}
+		w.Flush()
+
+	case "revoke":
+		if flags.NArg() != 1 {
+			flags.Usage()
+			return 2
+		}
+		if err := store.Revoke(flags.Arg(0)); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to revoke share link: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Revoked share %s\n", flags.Arg(0))
+
+	default:
+		fmt.Fprint(os.Stderr, shareUsage)
+		return 2
+	}
+
+	return 0
+}
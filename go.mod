@@ -3,8 +3,13 @@ module github.com/mano/mucal
 go 1.25.5
 
 require (
+	github.com/coreos/go-oidc/v3 v3.18.0
 	github.com/emersion/go-ical v0.0.0-20250609112844-439c63cef608
 	github.com/emersion/go-webdav v0.7.0
 	github.com/teambition/rrule-go v1.8.2
+	golang.org/x/crypto v0.54.0
+	golang.org/x/oauth2 v0.36.0
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/go-jose/go-jose/v4 v4.1.4
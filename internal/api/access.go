@@ -0,0 +1,174 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mano/mucal/internal/caldav"
+	"github.com/mano/mucal/internal/config"
+)
+
+// access is what the user of a request may see: the calendars of their
+// grant (or of the share link they use), narrowed down to the view they
+// selected, if any
+type access struct {
+	// grant is nil when calendars are not restricted per user
+	grant *config.Grant
+	view  *config.View
+
+	// privacy is the least restrictive privacy level shown, if any
+	privacy string
+}
+
+// accessFor returns the access of the user of a request, with the view
+// selected by the view query parameter
+func (s *state) accessFor(r *http.Request) (access, error) {
+	var a access
+	if sh := shareFromContext(r.Context()); sh != nil {
+		a.grant = &config.Grant{Calendars: sh.Calendars}
+		a.privacy = sh.Privacy
+	} else if s.config.Access.IsEnabled() {
+		grant := s.config.Access.GrantFor(UserFromContext(r.Context()))
+		a.grant = &grant
+	}
+
+	if name := r.URL.Query().Get("view"); name != "" {
+		if a.grant == nil {
+			return access{}, fmt.Errorf("unknown view: %s", name)
+		}
+		view, ok := a.grant.View(name)
+		if !ok {
+			return access{}, fmt.Errorf("unknown view: %s", name)
+		}
+		a.view = &view
+	}
+
+	return a, nil
+}
+
+// visibleSources returns the sources the user of a request may see, writing
+// an error response and returning false if the selected view is unknown
+func (s *state) visibleSources(w http.ResponseWriter, r *http.Request) ([]caldav.Source, access, bool) {
+	a, err := s.accessFor(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return nil, access{}, false
+	}
+	return a.sources(s.sources), a, true
+}
+
+// allows reports whether the calendar with the given configured name is
+// visible
+func (a access) allows(name string) bool {
+	if a.grant != nil && !a.grant.Allows(name) {
+		return false
+	}
+	return a.view == nil || a.view.Includes(name)
+}
+
+// privacyOf returns the privacy level a calendar is shown with, the stricter
+// of its own and that of the access
+func (a access) privacyOf(cal *config.Calendar) string {
+	return stricterPrivacy(cal.GetPrivacy(), a.privacy)
+}
+
+// sources returns the visible sources among the given ones
+func (a access) sources(sources []caldav.Source) []caldav.Source {
+	var visible []caldav.Source
+	for _, source := range sources {
+		if a.allows(source.GetCalendarName()) {
+			visible = append(visible, source)
+		}
+	}
+	return visible
+}
+
+// calendarName returns the name a calendar is shown with to the user
+func (a access) calendarName(name string) string {
+	if a.grant != nil {
+		if override, ok := a.grant.Overrides[name]; ok && override.Name != "" {
+			return override.Name
+		}
+	}
+	return name
+}
+
+// calendarColor returns the color a calendar is shown with to the user
+func (a access) calendarColor(cal *config.Calendar) string {
+	if a.grant != nil {
+		if override, ok := a.grant.Overrides[cal.Name]; ok && override.Color != "" {
+			return override.Color
+		}
+	}
+	return cal.Color
+}
+
+// sourcesNamed returns the visible sources shown with the given name
+func (a access) sourcesNamed(sources []caldav.Source, name string) []caldav.Source {
+	var named []caldav.Source
+	for _, source := range a.sources(sources) {
+		if a.calendarName(source.GetCalendarName()) == name {
+			named = append(named, source)
+		}
+	}
+	return named
+}
+
+// presentEvents shows the events of a calendar with the name and color of
+// the user
+func (a access) presentEvents(cal *config.Calendar, events []*caldav.Event) {
+	name, color := a.calendarName(cal.Name), a.calendarColor(cal)
+	for _, event := range events {
+		event.CalendarName, event.CalendarColor = name, color
+	}
+}
+
+// presentTasks shows the tasks of a calendar with the name and color of the
+// user
+func (a access) presentTasks(cal *config.Calendar, tasks []*caldav.Task) {
+	name, color := a.calendarName(cal.Name), a.calendarColor(cal)
+	for _, task := range tasks {
+		task.CalendarName, task.CalendarColor = name, color
+	}
+}
+
+// calendars lists the visible calendars of the configuration, as shown to
+// the user
+func (a access) calendars(cals []config.Calendar) []map[string]interface{} {
+	visible := make([]map[string]interface{}, 0, len(cals))
+	for i := range cals {
+		if !a.allows(cals[i].Name) {
+			continue
+		}
+		visible = append(visible, map[string]interface{}{
+			"name":  a.calendarName(cals[i].Name),
+			"color": a.calendarColor(&cals[i]),
+		})
+	}
+	return visible
+}
+
+// viewNames lists the names of the views of the user
+func (a access) viewNames() []string {
+	names := []string{}
+	if a.grant != nil {
+		for _, view := range a.grant.Views {
+			names = append(names, view.Name)
+		}
+	}
+	return names
+}
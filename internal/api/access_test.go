@@ -0,0 +1,185 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mano/mucal/internal/config"
+)
+
+// newAccessHandler creates a handler of the personal and work calendars
+// behind a reverse proxy, where everyone sees the work calendar and ada also
+// sees the personal one, with work renamed to Office
+func newAccessHandler(t *testing.T) http.Handler {
+	t.Helper()
+
+	server := newTestServer(t)
+	return newHandlerFor(t, &config.Config{
+		TimeZone:     "Europe/Rome",
+		AutoRefresh:  60,
+		SyncInterval: 3600,
+		Calendars:    []config.Calendar{testCalendar(t, server, "personal"), testCalendar(t, server, "work")},
+		Auth: config.Auth{
+			Mode:  config.AuthProxy,
+			Proxy: config.ProxyAuth{TrustedProxies: []string{"192.0.2.0/24"}},
+		},
+		Access: config.Access{
+			Groups: []config.Group{
+				{Name: "team", Members: []string{"*"}, Grant: config.Grant{Calendars: []string{"work"}}},
+			},
+			Users: []config.UserAccess{
+				{Username: "ada", Grant: config.Grant{
+					Calendars: []string{"personal"},
+					Overrides: map[string]config.CalendarOverride{"work": {Name: "Office", Color: "#FF6B6B"}},
+					Views:     []config.View{{Name: "Work only", Calendars: []string{"work"}}},
+				}},
+			},
+		},
+	})
+}
+
+// getAs performs a GET request as the given user, decoding the JSON
+// response into v
+func getAs(t *testing.T, h http.Handler, user, target string, v any) int {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.Header.Set("Remote-User", user)
+	rec := serve(h, r)
+
+	if v != nil && rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: invalid JSON %q: %v", target, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// calendarsOf returns the distinct calendar names of events, in order
+func calendarsOf(events []testEvent) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if !seen[event.Calendar] {
+			seen[event.Calendar] = true
+			names = append(names, event.Calendar)
+		}
+	}
+	return names
+}
+
+func TestAccessConfig(t *testing.T) {
+	h := newAccessHandler(t)
+
+	type calendar struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+	tests := []struct {
+		user      string
+		calendars []calendar
+		views     []string
+	}{
+		{user: "bob", calendars: []calendar{{Name: "work", Color: "#4ECDC4"}}, views: []string{}},
+		{
+			user:      "ada",
+			calendars: []calendar{{Name: "personal", Color: "#4ECDC4"}, {Name: "Office", Color: "#FF6B6B"}},
+			views:     []string{"Work only"},
+		},
+	}
+
+	for _, tt := range tests {
+		var response struct {
+			Calendars []calendar `json:"calendars"`
+			Views     []string   `json:"views"`
+		}
+		if code := getAs(t, h, tt.user, "/api/config", &response); code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", tt.user, code, http.StatusOK)
+		}
+		if !reflect.DeepEqual(response.Calendars, tt.calendars) || !reflect.DeepEqual(response.Views, tt.views) {
+			t.Errorf("%s: calendars %+v, views %v, want %+v, %v", tt.user, response.Calendars, response.Views, tt.calendars, tt.views)
+		}
+	}
+}
+
+func TestAccessEvents(t *testing.T) {
+	h := newAccessHandler(t)
+
+	tests := []struct {
+		user      string
+		query     string
+		calendars []string
+	}{
+		{user: "bob", calendars: []string{"work"}},
+		{user: "ada", calendars: []string{"personal", "Office"}},
+		{user: "ada", query: "&view=Work+only", calendars: []string{"Office"}},
+	}
+
+	for _, tt := range tests {
+		var response struct {
+			Events []testEvent `json:"events"`
+		}
+		target := "/api/events?start=2026-10-05&end=2026-10-25" + tt.query
+		if code := getAs(t, h, tt.user, target, &response); code != http.StatusOK {
+			t.Fatalf("%s%s: status = %d, want %d", tt.user, tt.query, code, http.StatusOK)
+		}
+		if got := calendarsOf(response.Events); !reflect.DeepEqual(got, tt.calendars) {
+			t.Errorf("%s%s: events of %v, want %v", tt.user, tt.query, got, tt.calendars)
+		}
+	}
+
+	// The days of the month follow the same calendars
+	var bobDays, viewDays, adaDays struct {
+		Days []int `json:"days"`
+	}
+	getAs(t, h, "bob", "/api/events/month?year=2026&month=10", &bobDays)
+	getAs(t, h, "ada", "/api/events/month?year=2026&month=10&view=Work+only", &viewDays)
+	getAs(t, h, "ada", "/api/events/month?year=2026&month=10", &adaDays)
+	if len(bobDays.Days) != len(viewDays.Days) || len(adaDays.Days) <= len(bobDays.Days) {
+		t.Errorf("month days: bob %v, ada's view %v, ada %v", bobDays.Days, viewDays.Days, adaDays.Days)
+	}
+
+	// Views belong to their users
+	if code := getAs(t, h, "bob", "/api/events?start=2026-10-05&end=2026-10-25&view=Work+only", nil); code != http.StatusNotFound {
+		t.Errorf("view of another user: status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestAccessFeeds(t *testing.T) {
+	h := newAccessHandler(t)
+
+	tests := []struct {
+		user   string
+		target string
+		status int
+	}{
+		{user: "bob", target: "/api/calendars/work.ics", status: http.StatusOK},
+		{user: "bob", target: "/api/calendars/personal.ics", status: http.StatusNotFound},
+		{user: "bob", target: "/api/calendar.ics?calendar=personal", status: http.StatusNotFound},
+		{user: "ada", target: "/api/calendars/Office.ics", status: http.StatusOK},
+		{user: "ada", target: "/api/calendars/personal.ics", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if code := getAs(t, h, tt.user, tt.target, nil); code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d", tt.user, tt.target, code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,497 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/logging"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// sessionCookie holds the signed session of a logged in user
+	sessionCookie = "mucal_session"
+
+	// loginCookie holds the state of an OpenID Connect login in progress
+	loginCookie = "mucal_login"
+
+	// loginTTL is how long an OpenID Connect login may take
+	loginTTL = 10 * time.Minute
+
+	// loginURLHeader tells the frontend where to send users whose session
+	// is missing or expired
+	loginURLHeader = "X-Login-URL"
+)
+
+// dummyHash returns the hash compared against the passwords of unknown
+// users, so that they take as long to reject as wrong passwords. It is only
+// computed on the first login of an unknown user.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("mucal"), bcrypt.DefaultCost)
+	return hash
+})
+
+// userKey is the context key of the authenticated user name
+type userKey struct{}
+
+// UserFromContext returns the name of the user authenticated for a request,
+// or "" when authentication is disabled
+func UserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// authenticator checks who is calling the web UI and API, according to the
+// authentication mode of the configuration
+type authenticator struct {
+	mode     string
+	users    map[string][]byte
+	header   string
+	trusted  []*net.IPNet
+	sessions *signer
+	ttl      time.Duration
+	oidc     *oidcLogin
+	metrics  string
+}
+
+// oidcLogin holds the OpenID Connect provider users log in with
+type oidcLogin struct {
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	claim    string
+	allowed  map[string]bool
+}
+
+// newAuthenticator creates the authenticator of the given configuration. In
+// OIDC mode, the provider is discovered from its issuer URL.
+func newAuthenticator(ctx context.Context, cfg *config.Auth) (*authenticator, error) {
+	secret, err := cfg.GetSessionSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session secret: %w", err)
+	}
+	if secret == nil {
+		// Sessions do not survive restarts
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+
+	metricsToken, err := cfg.GetMetricsToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics token: %w", err)
+	}
+
+	a := &authenticator{
+		mode:     cfg.GetMode(),
+		sessions: &signer{key: secret},
+		ttl:      cfg.GetSessionTTL(),
+		metrics:  metricsToken,
+	}
+
+	switch a.mode {
+	case config.AuthLocal:
+		a.users = make(map[string][]byte)
+		for _, user := range cfg.Users {
+			a.users[user.Username] = []byte(user.PasswordHash)
+		}
+	case config.AuthProxy:
+		a.header = cfg.Proxy.GetUserHeader()
+		if a.trusted, err = cfg.Proxy.TrustedNetworks(); err != nil {
+			return nil, err
+		}
+	case config.AuthOIDC:
+		if a.oidc, err = newOIDCLogin(ctx, &cfg.OIDC); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// newOIDCLogin discovers an OpenID Connect provider
+func newOIDCLogin(ctx context.Context, cfg *config.OIDCAuth) (*oidcLogin, error) {
+	secret, err := cfg.GetClientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider %s: %w", cfg.Issuer, err)
+	}
+
+	login := &oidcLogin{
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: secret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       cfg.GetScopes(),
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		claim:    cfg.GetUsernameClaim(),
+	}
+	if len(cfg.AllowedUsers) > 0 {
+		login.allowed = make(map[string]bool)
+		for _, user := range cfg.AllowedUsers {
+			login.allowed[user] = true
+		}
+	}
+
+	return login, nil
+}
+
+// middleware rejects the requests of unauthenticated users with a 401 error,
+// and those of users who are not allowed in with a 403 error. The health
+// checks and the login endpoints are always accessible. With a metrics token,
+// the metrics are only accessible with it, and without a user.
+func (a *authenticator) middleware(next http.Handler) http.Handler {
+	if a.mode == config.AuthNone {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/health" || r.URL.Path == "/api/health/ready" || strings.HasPrefix(r.URL.Path, "/auth/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.URL.Path == "/metrics" && a.metrics != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.metrics)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="μCal"`)
+				writeError(w, http.StatusUnauthorized, "invalid metrics token")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, status, message := a.authenticate(w, r)
+		if status != http.StatusOK {
+			a.deny(w, r, status, message)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+// authenticate returns the user making a request, or the status and message
+// of the error rejecting it
+func (a *authenticator) authenticate(w http.ResponseWriter, r *http.Request) (string, int, string) {
+	switch a.mode {
+	case config.AuthProxy:
+		if !a.isTrusted(r.RemoteAddr) {
+			return "", http.StatusForbidden, "request not received from a trusted proxy"
+		}
+		user := strings.TrimSpace(r.Header.Get(a.header))
+		if user == "" {
+			return "", http.StatusUnauthorized, fmt.Sprintf("missing %s header", a.header)
+		}
+		return user, http.StatusOK, ""
+	}
+
+	// Local and OIDC users are remembered by a session cookie
+	if user, ok := a.sessionUser(r); ok {
+		return user, http.StatusOK, ""
+	}
+
+	if a.mode == config.AuthLocal {
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			return "", http.StatusUnauthorized, "authentication required"
+		}
+
+		hash, known := a.users[username]
+		if !known {
+			hash = dummyHash()
+		}
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || !known {
+			return "", http.StatusUnauthorized, "invalid username or password"
+		}
+
+		// Spare the next requests a bcrypt comparison
+		a.setSession(w, r, username)
+		return username, http.StatusOK, ""
+	}
+
+	return "", http.StatusUnauthorized, "login required"
+}
+
+// deny writes the error of an unauthenticated or forbidden request. Local
+// users are asked for their password; pages are redirected to the OIDC
+// login, and API clients told where it is.
+func (a *authenticator) deny(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if status == http.StatusUnauthorized {
+		switch a.mode {
+		case config.AuthLocal:
+			w.Header().Set("WWW-Authenticate", `Basic realm="μCal", charset="UTF-8"`)
+		case config.AuthOIDC:
+			login := "/auth/login?next=" + url.QueryEscape(r.URL.RequestURI())
+			if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") {
+				http.Redirect(w, r, login, http.StatusFound)
+				return
+			}
+			w.Header().Set(loginURLHeader, "/auth/login")
+		}
+	}
+
+	writeError(w, status, message)
+}
+
+// isTrusted reports whether a remote address belongs to a trusted proxy
+func (a *authenticator) isTrusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range a.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// session is the content of the session cookie
+type session struct {
+	User    string `json:"u"`
+	Expires int64  `json:"e"`
+}
+
+// sessionUser returns the user of a valid session cookie
+func (a *authenticator) sessionUser(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return "", false
+	}
+
+	var s session
+	if !a.sessions.decode(cookie.Value, &s) || time.Now().Unix() >= s.Expires || s.User == "" {
+		return "", false
+	}
+
+	// Local users removed from the configuration are logged out
+	if a.mode == config.AuthLocal {
+		if _, ok := a.users[s.User]; !ok {
+			return "", false
+		}
+	}
+
+	return s.User, true
+}
+
+// setSession logs a user in, until the session expires
+func (a *authenticator) setSession(w http.ResponseWriter, r *http.Request, user string) {
+	expires := time.Now().Add(a.ttl)
+	a.setCookie(w, r, sessionCookie, a.sessions.encode(session{User: user, Expires: expires.Unix()}), expires)
+}
+
+// setCookie sets a signed cookie, or deletes it when expires is zero
+func (a *authenticator) setCookie(w http.ResponseWriter, r *http.Request, name, value string, expires time.Time) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+	if expires.IsZero() {
+		cookie.MaxAge = -1
+	} else {
+		cookie.Expires = expires
+	}
+	http.SetCookie(w, cookie)
+}
+
+// setupRoutes registers the login and logout endpoints
+func (a *authenticator) setupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/auth/logout", a.logout)
+	if a.oidc != nil {
+		mux.HandleFunc("/auth/login", a.login)
+		mux.HandleFunc("/auth/callback", a.callback)
+	}
+}
+
+// loginState is the content of the login cookie, checked when the provider
+// redirects back to μCal
+type loginState struct {
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+	Next     string `json:"r"`
+	Expires  int64  `json:"e"`
+}
+
+// login redirects to the OpenID Connect provider. The optional next query
+// parameter is the page to return to once logged in.
+func (a *authenticator) login(w http.ResponseWriter, r *http.Request) {
+	state := loginState{
+		State:    randomToken(),
+		Nonce:    randomToken(),
+		Verifier: oauth2.GenerateVerifier(),
+		Next:     localPath(r.URL.Query().Get("next")),
+		Expires:  time.Now().Add(loginTTL).Unix(),
+	}
+	a.setCookie(w, r, loginCookie, a.sessions.encode(state), time.Unix(state.Expires, 0))
+
+	target := a.oidc.oauth2.AuthCodeURL(state.State, oidc.Nonce(state.Nonce), oauth2.S256ChallengeOption(state.Verifier))
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// callback completes an OpenID Connect login, starting the session of the
+// user if they are allowed in
+func (a *authenticator) callback(w http.ResponseWriter, r *http.Request) {
+	var state loginState
+	cookie, err := r.Cookie(loginCookie)
+	if err != nil || !a.sessions.decode(cookie.Value, &state) || time.Now().Unix() >= state.Expires {
+		writeError(w, http.StatusBadRequest, "login expired, please try again")
+		return
+	}
+	a.setCookie(w, r, loginCookie, "", time.Time{})
+
+	query := r.URL.Query()
+	if message := query.Get("error"); message != "" {
+		writeError(w, http.StatusUnauthorized, fmt.Sprintf("login failed: %s %s", message, query.Get("error_description")))
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state.State)) != 1 {
+		writeError(w, http.StatusBadRequest, "invalid login state")
+		return
+	}
+
+	token, err := a.oidc.oauth2.Exchange(r.Context(), query.Get("code"), oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("OIDC code exchange failed", "error", err)
+		writeError(w, http.StatusUnauthorized, "login failed")
+		return
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "login failed: no ID token")
+		return
+	}
+	idToken, err := a.oidc.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("OIDC ID token verification failed", "error", err)
+		writeError(w, http.StatusUnauthorized, "login failed")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(state.Nonce)) != 1 {
+		writeError(w, http.StatusUnauthorized, "login failed: invalid nonce")
+		return
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		writeError(w, http.StatusUnauthorized, "login failed: invalid claims")
+		return
+	}
+	user, _ := claims[a.oidc.claim].(string)
+	if user == "" {
+		writeError(w, http.StatusUnauthorized, fmt.Sprintf("login failed: no %s claim", a.oidc.claim))
+		return
+	}
+	if a.oidc.allowed != nil && !a.oidc.allowed[user] {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("user %s is not allowed", user))
+		return
+	}
+
+	a.setSession(w, r, user)
+	http.Redirect(w, r, state.Next, http.StatusFound)
+}
+
+// logout ends the session of the user
+func (a *authenticator) logout(w http.ResponseWriter, r *http.Request) {
+	a.setCookie(w, r, sessionCookie, "", time.Time{})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// localPath returns a path of μCal to redirect to, "/" unless p is a local
+// absolute path
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return "/"
+	}
+	return p
+}
+
+// randomToken returns a random URL-safe string
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signer encodes values as JSON signed with HMAC-SHA256, so that they can be
+// kept by the browser without being tampered with
+type signer struct {
+	key []byte
+}
+
+// encode returns the signed encoding of v
+func (s *signer) encode(v any) string {
+	data, _ := json.Marshal(v)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// decode checks the signature of an encoded value and decodes it into v
+func (s *signer) decode(value string, v any) bool {
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(payload)) {
+		return false
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// sign returns the HMAC-SHA256 of a payload
+func (s *signer) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,362 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/mano/mucal/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newAuthHandler creates a handler of the personal calendar requiring the
+// given authentication
+func newAuthHandler(t *testing.T, auth config.Auth) http.Handler {
+	t.Helper()
+
+	return newHandlerFor(t, &config.Config{
+		TimeZone:     "Europe/Rome",
+		AutoRefresh:  60,
+		SyncInterval: 3600,
+		Calendars:    []config.Calendar{testCalendar(t, newTestServer(t), "personal")},
+		Auth:         auth,
+	})
+}
+
+// serve performs a request with the given cookies, returning the response
+func serve(h http.Handler, r *http.Request, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+// responseCookie returns the cookie of a response with the given name
+func responseCookie(t *testing.T, rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	t.Helper()
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	t.Fatalf("response sets no %s cookie", name)
+	return nil
+}
+
+// assertError checks the status and JSON error format of a response
+func assertError(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d", rec.Code, status)
+	}
+	var response struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || response.Error == "" || response.Code != status {
+		t.Errorf("invalid error response %q", rec.Body.String())
+	}
+}
+
+func TestAuthLocal(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newAuthHandler(t, config.Auth{
+		Mode:  config.AuthLocal,
+		Users: []config.User{{Username: "ada", PasswordHash: string(hash)}},
+	})
+
+	// The health check is public
+	if rec := serve(h, httptest.NewRequest(http.MethodGet, "/api/health", nil)); rec.Code != http.StatusOK {
+		t.Errorf("health status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	assertError(t, rec, http.StatusUnauthorized)
+	if !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Basic ") {
+		t.Errorf("WWW-Authenticate = %q, want Basic", rec.Header().Get("WWW-Authenticate"))
+	}
+
+	for _, credentials := range [][2]string{{"ada", "wrong"}, {"bob", "secret"}} {
+		r := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+		r.SetBasicAuth(credentials[0], credentials[1])
+		assertError(t, serve(h, r), http.StatusUnauthorized)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+	r.SetBasicAuth("ada", "secret")
+	rec = serve(h, r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// Later requests are authenticated by the session cookie
+	session := responseCookie(t, rec, sessionCookie)
+	var response struct {
+		User string `json:"user"`
+	}
+	if code := get(t, cookieHandler(h, session), "/api/config", &response); code != http.StatusOK || response.User != "ada" {
+		t.Errorf("session request: status %d, user %q", code, response.User)
+	}
+
+	// Tampered sessions are rejected
+	session.Value = strings.Replace(session.Value, ".", "x.", 1)
+	assertError(t, serve(h, httptest.NewRequest(http.MethodGet, "/api/config", nil), session), http.StatusUnauthorized)
+}
+
+// cookieHandler adds a cookie to the requests of a handler
+func cookieHandler(h http.Handler, cookie *http.Cookie) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.AddCookie(cookie)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func TestAuthProxy(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted string
+		user    string
+		status  int
+	}{
+		{name: "trusted", trusted: "192.0.2.0/24", user: "ada", status: http.StatusOK},
+		{name: "missing header", trusted: "192.0.2.1", status: http.StatusUnauthorized},
+		{name: "untrusted", trusted: "10.0.0.1", user: "ada", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newAuthHandler(t, config.Auth{
+				Mode:  config.AuthProxy,
+				Proxy: config.ProxyAuth{TrustedProxies: []string{tt.trusted}},
+			})
+
+			// httptest requests come from 192.0.2.1
+			r := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+			if tt.user != "" {
+				r.Header.Set("Remote-User", tt.user)
+			}
+			rec := serve(h, r)
+			if tt.status != http.StatusOK {
+				assertError(t, rec, tt.status)
+				return
+			}
+
+			var response struct {
+				User string `json:"user"`
+			}
+			if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &response) != nil || response.User != tt.user {
+				t.Errorf("status %d, body %q, want user %s", rec.Code, rec.Body.String(), tt.user)
+			}
+		})
+	}
+}
+
+// fakeProvider is an OpenID Connect provider issuing ID tokens for a user
+type fakeProvider struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	user  string
+	nonce string
+}
+
+// newFakeProvider starts an OpenID Connect provider, stopped when the test
+// ends
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakeProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &p.key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: p.key},
+			(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		claims, _ := json.Marshal(map[string]any{
+			"iss":                p.URL,
+			"sub":                "id-" + p.user,
+			"aud":                "mucal",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"iat":                time.Now().Unix(),
+			"nonce":              p.nonce,
+			"preferred_username": p.user,
+		})
+		signed, err := signer.Sign(claims)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		idToken, _ := signed.CompactSerialize()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestAuthOIDC(t *testing.T) {
+	provider := newFakeProvider(t)
+
+	secretFile := filepath.Join(t.TempDir(), "client-secret.txt")
+	if err := os.WriteFile(secretFile, []byte("client-secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	h := newAuthHandler(t, config.Auth{
+		Mode: config.AuthOIDC,
+		OIDC: config.OIDCAuth{
+			Issuer:           provider.URL,
+			ClientID:         "mucal",
+			ClientSecretFile: secretFile,
+			RedirectURL:      "http://mucal.example.com/auth/callback",
+			AllowedUsers:     []string{"ada"},
+		},
+	})
+
+	// API clients are told where to log in, pages are redirected there
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/api/events", nil))
+	assertError(t, rec, http.StatusUnauthorized)
+	if rec.Header().Get(loginURLHeader) != "/auth/login" {
+		t.Errorf("%s = %q, want /auth/login", loginURLHeader, rec.Header().Get(loginURLHeader))
+	}
+	rec = serve(h, httptest.NewRequest(http.MethodGet, "/?week=2", nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/auth/login?next=%2F%3Fweek%3D2" {
+		t.Errorf("page: status %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	// login logs a user in, returning the session cookie
+	login := func(user string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rec := serve(h, httptest.NewRequest(http.MethodGet, "/auth/login?next=/%3Fweek%3D2", nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("login status = %d, want %d", rec.Code, http.StatusFound)
+		}
+		authorize, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil || !strings.HasPrefix(authorize.String(), provider.URL+"/authorize") {
+			t.Fatalf("login redirects to %q", rec.Header().Get("Location"))
+		}
+		query := authorize.Query()
+		if query.Get("code_challenge_method") != "S256" {
+			t.Errorf("login does not use PKCE: %s", authorize)
+		}
+
+		// The provider authenticates the user and redirects back
+		provider.user, provider.nonce = user, query.Get("nonce")
+		callback := "/auth/callback?code=code&state=" + url.QueryEscape(query.Get("state"))
+		return serve(h, httptest.NewRequest(http.MethodGet, callback, nil), responseCookie(t, rec, loginCookie))
+	}
+
+	rec = login("ada")
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/?week=2" {
+		t.Fatalf("callback: status %d, location %q, body %q", rec.Code, rec.Header().Get("Location"), rec.Body.String())
+	}
+	var response struct {
+		User string `json:"user"`
+	}
+	if code := get(t, cookieHandler(h, responseCookie(t, rec, sessionCookie)), "/api/config", &response); code != http.StatusOK || response.User != "ada" {
+		t.Errorf("session request: status %d, user %q", code, response.User)
+	}
+
+	// Users not in allowed_users are refused
+	assertError(t, login("bob"), http.StatusForbidden)
+
+	// Callbacks without the login cookie are refused
+	assertError(t, serve(h, httptest.NewRequest(http.MethodGet, "/auth/callback?code=code&state=x", nil)), http.StatusBadRequest)
+}
+
+func TestAuthMetricsToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "metrics.txt")
+	if err := os.WriteFile(tokenFile, []byte("scrape\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newAuthHandler(t, config.Auth{
+		Mode:             config.AuthLocal,
+		Users:            []config.User{{Username: "ada", PasswordHash: string(hash)}},
+		MetricsTokenFile: tokenFile,
+	})
+
+	// The token is the only way in, users included
+	for _, authorization := range []string{"", "Bearer wrong", "Basic YWRhOnNlY3JldA=="} {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		rec := serve(h, r)
+		assertError(t, rec, http.StatusUnauthorized)
+		if !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer ") {
+			t.Errorf("WWW-Authenticate = %q, want Bearer", rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.Header.Set("Authorization", "Bearer scrape")
+	if rec := serve(h, r); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// The token opens nothing else
+	r = httptest.NewRequest(http.MethodGet, "/api/config", nil)
+	r.Header.Set("Authorization", "Bearer scrape")
+	assertError(t, serve(h, r), http.StatusUnauthorized)
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mano/mucal/internal/caldav"
+)
+
+// GetFeed handles the aggregated iCalendar feed endpoint. Optional query
+// parameters: start and end (YYYY-MM-DD) restrict the feed to events in that
+// window, and calendar (repeatable) restricts it to the named calendars.
+// Only the calendars the user may see, or those of the selected view, are
+// included.
+func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	sources, a, ok := s.visibleSources(w, r)
+	if !ok {
+		return
+	}
+	if names := r.URL.Query()["calendar"]; len(names) > 0 {
+		sources = nil
+		for _, name := range names {
+			matched := a.sourcesNamed(s.sources, name)
+			if len(matched) == 0 {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("unknown calendar: %s", name))
+				return
+			}
+			sources = append(sources, matched...)
+		}
+	}
+
+	s.writeFeed(w, r, a, "μCal", sources)
+}
+
+// GetCalendarFeed handles the per-calendar iCalendar feed endpoint,
+// /api/calendars/{name}.ics, accepting the same window parameters as GetFeed
+func (h *Handler) GetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	file := strings.TrimPrefix(r.URL.Path, "/api/calendars/")
+	name, ok := strings.CutSuffix(file, ".ics")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	a, err := s.accessFor(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	sources := a.sourcesNamed(s.sources, name)
+	if len(sources) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown calendar: %s", name))
+		return
+	}
+
+	s.writeFeed(w, r, a, name, sources)
+}
+
+// writeFeed writes the cached objects of the given calendars as a feed
+func (s *state) writeFeed(w http.ResponseWriter, r *http.Request, a access, name string, sources []caldav.Source) {
+	start, end, err := s.parseFeedWindow(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	feed := caldav.NewFeed(name, s.timezone)
+	var failed failures
+	for _, source := range sources {
+		objects, err := s.cache.Objects(r.Context(), source)
+		if err != nil {
+			failed.add(r, a, source, err)
+			continue
+		}
+		feed.Add(r.Context(), source, redactObjects(objects, a.privacyOf(source.GetCalendar())), start, end)
+	}
+
+	// If all calendars failed, return error; otherwise the feed holds the
+	// others, as iCalendar has no room for warnings
+	if failed.all(len(sources)) {
+		failed.writeError(w, "calendars")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := feed.Encode(&buf); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to encode feed: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// parseFeedWindow parses the optional start and end query parameters, with
+// end inclusive. Zero times are returned when they are absent.
+func (s *state) parseFeedWindow(r *http.Request) (time.Time, time.Time, error) {
+	startStr := r.URL.Query().Get("start")
+	endStr := r.URL.Query().Get("end")
+
+	if startStr == "" && endStr == "" {
+		return time.Time{}, time.Time{}, nil
+	}
+	if startStr == "" || endStr == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("start and end query parameters must be given together (format: YYYY-MM-DD)")
+	}
+
+	start, err := time.ParseInLocation("2006-01-02", startStr, s.timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start date format: %v", err)
+	}
+
+	end, err := time.ParseInLocation("2006-01-02", endStr, s.timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end date format: %v", err)
+	}
+
+	// Add one day to end to make it inclusive
+	return start, end.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mano/mucal/internal/caldav"
+	"github.com/mano/mucal/internal/config"
+)
+
+// GetFreeBusy handles the free/busy endpoint, merging the busy time of all
+// calendars between start and end (YYYY-MM-DD, inclusive). Cancelled and
+// transparent events are left out, and so are calendars configured with
+// free_busy "none"; those with free_busy "busy" do not disclose their event
+// titles. The response is JSON, or an iCalendar VFREEBUSY document with
+// format=ics or when text/calendar is accepted. Only the calendars the user
+// may see, or those of the selected view, are merged.
+func (h *Handler) GetFreeBusy(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	start, end, err := s.parseDateRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	visible, a, ok := s.visibleSources(w, r)
+	if !ok {
+		return
+	}
+
+	var sources []caldav.Source
+	for _, source := range visible {
+		if source.GetCalendar().GetFreeBusy() != config.FreeBusyNone {
+			sources = append(sources, source)
+		}
+	}
+
+	var (
+		periods []caldav.BusyPeriod
+		failed  failures
+	)
+	for _, result := range s.fetchEvents(r.Context(), sources, start, end) {
+		if result.err != nil {
+			failed.add(r, a, result.source, result.err)
+			continue
+		}
+
+		calendar := result.source.GetCalendar()
+		details := calendar.GetFreeBusy() == config.FreeBusyDetails
+		a.presentEvents(calendar, result.events)
+		for _, event := range result.events {
+			redactEvent(event, a.privacyOf(calendar))
+			if period, ok := event.BusyPeriod(details); ok {
+				periods = append(periods, period)
+			}
+		}
+	}
+
+	// If all calendars failed, return error; otherwise report the failed
+	// ones in JSON responses
+	if failed.all(len(sources)) {
+		failed.writeError(w, "events")
+		return
+	}
+
+	busy := caldav.MergeBusy(periods, start, end)
+	if busy == nil {
+		// Free ranges are listed as empty, not null
+		busy = []caldav.BusyPeriod{}
+	}
+
+	if r.URL.Query().Get("format") == "ics" || strings.Contains(r.Header.Get("Accept"), "text/calendar") {
+		var buf bytes.Buffer
+		if err := caldav.EncodeFreeBusy(&buf, busy, start, end); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to encode free/busy: %v", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+		return
+	}
+
+	response := map[string]interface{}{
+		"start": start,
+		"end":   end,
+		"busy":  busy,
+	}
+	failed.report(response)
+	writeJSON(w, http.StatusOK, response)
+}
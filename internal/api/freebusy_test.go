@@ -0,0 +1,143 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mano/mucal/internal/config"
+)
+
+func TestFreeBusy(t *testing.T) {
+	type busyEvent struct {
+		Summary  string `json:"summary"`
+		Calendar string `json:"calendarName"`
+	}
+	type busyPeriod struct {
+		Start  string      `json:"start"`
+		End    string      `json:"end"`
+		Type   string      `json:"type"`
+		Events []busyEvent `json:"events"`
+	}
+
+	tests := []struct {
+		name         string
+		workFreeBusy string
+		want         []busyPeriod
+	}{
+		{
+			// The weekly sync of the work calendar overlaps the standup,
+			// but its title is not disclosed. Cancelled and transparent
+			// events leave the time free.
+			name:         "busy only",
+			workFreeBusy: config.FreeBusyBusy,
+			want: []busyPeriod{
+				{Start: "2026-10-19T09:00:00+02:00", End: "2026-10-19T09:30:00+02:00", Type: "BUSY",
+					Events: []busyEvent{{Summary: "Standup", Calendar: "personal"}}},
+				{Start: "2026-10-19T12:30:00+02:00", End: "2026-10-19T13:30:00+02:00", Type: "BUSY-TENTATIVE",
+					Events: []busyEvent{{Summary: "Maybe lunch", Calendar: "personal"}}},
+			},
+		},
+		{
+			name:         "details",
+			workFreeBusy: config.FreeBusyDetails,
+			want: []busyPeriod{
+				{Start: "2026-10-19T09:00:00+02:00", End: "2026-10-19T09:30:00+02:00", Type: "BUSY",
+					Events: []busyEvent{{Summary: "Standup", Calendar: "personal"}, {Summary: "Weekly sync", Calendar: "work"}}},
+				{Start: "2026-10-19T12:30:00+02:00", End: "2026-10-19T13:30:00+02:00", Type: "BUSY-TENTATIVE",
+					Events: []busyEvent{{Summary: "Maybe lunch", Calendar: "personal"}}},
+			},
+		},
+		{
+			name:         "excluded",
+			workFreeBusy: config.FreeBusyNone,
+			want: []busyPeriod{
+				{Start: "2026-10-19T09:00:00+02:00", End: "2026-10-19T09:15:00+02:00", Type: "BUSY",
+					Events: []busyEvent{{Summary: "Standup", Calendar: "personal"}}},
+				{Start: "2026-10-19T12:30:00+02:00", End: "2026-10-19T13:30:00+02:00", Type: "BUSY-TENTATIVE",
+					Events: []busyEvent{{Summary: "Maybe lunch", Calendar: "personal"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			work := testCalendar(t, server, "work")
+			work.FreeBusy = tt.workFreeBusy
+			h := newConfiguredHandler(t, testCalendar(t, server, "personal"), work)
+
+			var response struct {
+				Busy []busyPeriod `json:"busy"`
+			}
+			if code := get(t, h, "/api/freebusy?start=2026-10-19&end=2026-10-19", &response); code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", code, http.StatusOK)
+			}
+			if !reflect.DeepEqual(response.Busy, tt.want) {
+				t.Errorf("busy =\n%+v\nwant\n%+v", response.Busy, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreeBusyFree(t *testing.T) {
+	server := newTestServer(t)
+	h := newTestHandler(t, server, "personal", "work")
+
+	var response map[string]json.RawMessage
+	if code := get(t, h, "/api/freebusy?start=2030-01-01&end=2030-01-01", &response); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	if busy := string(response["busy"]); busy != "[]" {
+		t.Errorf("busy = %s, want []", busy)
+	}
+}
+
+func TestFreeBusyICalendar(t *testing.T) {
+	server := newTestServer(t)
+	h := newTestHandler(t, server, "personal", "work")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/freebusy?start=2026-10-19&end=2026-10-19&format=ics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("Content-Type = %q, want text/calendar", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"BEGIN:VFREEBUSY",
+		"DTSTART:20261018T220000Z",
+		"DTEND:20261019T220000Z",
+		"FREEBUSY;FBTYPE=BUSY:20261019T070000Z/20261019T073000Z",
+		"FREEBUSY;FBTYPE=BUSY-TENTATIVE:20261019T103000Z/20261019T113000Z",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("free/busy document lacks %q:\n%s", want, body)
+		}
+	}
+
+	// Event titles are never part of the document
+	if strings.Contains(body, "Standup") {
+		t.Errorf("free/busy document discloses event titles:\n%s", body)
+	}
+}
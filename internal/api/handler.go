@@ -15,52 +15,95 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
 	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/mano/mucal/internal/cache"
 	"github.com/mano/mucal/internal/caldav"
 	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/share"
 	"github.com/mano/mucal/internal/version"
 )
 
 // Handler handles HTTP requests for the API
 type Handler struct {
-	config   *config.Config
-	clients  []*caldav.Client
-	timezone *time.Location
-	version  string
+	// state is replaced when the configuration is reloaded
+	state   atomic.Pointer[state]
+	broker  *broker
+	auth    *authenticator
+	shares  *share.Store
+	mux     *http.ServeMux
+	version string
+
+	// reloadMu serializes reloads and closing. reload holds the outcome of
+	// the last reload, read by status requests without waiting for the one
+	// in progress.
+	reloadMu sync.Mutex
+	reload   atomic.Pointer[reloadStatus]
+	closed   bool
 }
 
 // NewHandler creates a new API handler
 func NewHandler(cfg *config.Config) (*Handler, error) {
-	// Get timezone
-	tz, err := cfg.GetLocation()
+	// Set up authentication first, as OIDC providers are discovered over
+	// the network
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	auth, err := newAuthenticator(ctx, &cfg.Auth)
+	cancel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load timezone: %w", err)
+		return nil, fmt.Errorf("failed to set up authentication: %w", err)
 	}
 
-	// Create CalDAV clients for each calendar
-	var clients []*caldav.Client
-	for i := range cfg.Calendars {
-		client, err := caldav.NewClient(&cfg.Calendars[i], tz)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create client for calendar %s: %w", cfg.Calendars[i].Name, err)
+	var shares *share.Store
+	if cfg.Shares.IsEnabled() {
+		if shares, err = share.Open(cfg.Shares.StateFile); err != nil {
+			return nil, fmt.Errorf("failed to open share links: %w", err)
 		}
-		clients = append(clients, client)
 	}
 
-	return &Handler{
-		config:   cfg,
-		clients:  clients,
-		timezone: tz,
-		version:  version.Version,
-	}, nil
+	// Keep calendars synced in the background, notifying event streams of
+	// any change
+	changes := newBroker()
+	s, err := newState(cfg, nil, changes.publish)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Start()
+
+	h := &Handler{
+		broker:  changes,
+		auth:    auth,
+		shares:  shares,
+		version: version.Version,
+	}
+	h.state.Store(s)
+	h.reload.Store(&reloadStatus{LoadedAt: time.Now()})
+	return h, nil
+}
+
+// Close stops the background calendar sync and disconnects event streams
+func (h *Handler) Close() {
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+
+	h.closed = true
+	h.broker.close()
+	h.current().cache.Stop()
+}
+
+// AuthMiddleware rejects the requests of users who are not authenticated,
+// according to the authentication mode of the configuration. Requests made
+// with a valid share link are let through, within the scope of the share.
+func (h *Handler) AuthMiddleware(next http.Handler) http.Handler {
+	return h.shareMiddleware(h.auth.middleware(next), next)
 }
 
 // Health handles the health check endpoint
@@ -72,92 +115,236 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, response)
 }
 
-// GetConfig handles the config endpoint (sanitized, no credentials)
-func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, h.config.Sanitize())
+// Ready handles the readiness check endpoint, failing with 503 while a
+// calendar marked as required is stale: none of its syncs succeeded yet, the
+// last one failed, or none succeeded for two sync intervals. Calendar names
+// are left to the status endpoint, as this one needs no authentication.
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	failing := 0
+	for _, status := range s.cache.Status() {
+		if status.Required && status.Stale {
+			failing++
+		}
+	}
+
+	if failing > 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":  "unavailable",
+			"failing": failing,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ready",
+	})
 }
 
-// GetEvents handles the events endpoint
-func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
-	startStr := r.URL.Query().Get("start")
-	endStr := r.URL.Query().Get("end")
+// GetStatus handles the status endpoint, detailing the sync state of each
+// calendar the user may see, and when the configuration was loaded along
+// with the error of the last reload, if it failed, and those of account
+// discovery. Among the calendars the user may see, the overall status is
+// "unavailable" when a required one is stale, as reported by the readiness
+// check, and "degraded" when any other is. It is also "degraded" when the
+// last reload failed or an account could not be discovered, and "ok"
+// otherwise.
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	a, err := s.accessFor(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
 
-	if startStr == "" || endStr == "" {
-		writeError(w, http.StatusBadRequest, "start and end query parameters are required (format: YYYY-MM-DD)")
+	overall := "ok"
+	for _, status := range s.cache.Status() {
+		if !status.Stale || !a.allows(status.Name) {
+			continue
+		}
+		if status.Required {
+			overall = "unavailable"
+			break
+		}
+		overall = "degraded"
+	}
+
+	response := map[string]interface{}{
+		"status":    overall,
+		"version":   h.version,
+		"calendars": s.syncStatuses(a),
+	}
+
+	// Share links do not disclose the configuration
+	if shareFromContext(r.Context()) == nil {
+		reload := h.lastReload()
+		if (reload.Error != "" || len(reload.Discovery) > 0) && overall == "ok" {
+			response["status"] = "degraded"
+		}
+		response["config"] = reload
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetConfig handles the config endpoint (sanitized, no credentials), along
+// with the name of the authenticated user. Only the calendars the user may
+// see are listed, with their own names and colors, and so are their views.
+func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	a, err := s.accessFor(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
-	// Parse dates
-	start, err := time.ParseInLocation("2006-01-02", startStr, h.timezone)
+	response := s.config.Sanitize()
+	response["calendars"] = a.calendars(s.config.Calendars)
+	response["views"] = a.viewNames()
+	if user := UserFromContext(r.Context()); user != "" {
+		response["user"] = user
+	}
+	if sh := shareFromContext(r.Context()); sh != nil {
+		response["share"] = sh.Label
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetSyncStatus handles the sync status endpoint, reporting the last
+// successful sync and staleness of each calendar
+func (h *Handler) GetSyncStatus(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	a, err := s.accessFor(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid start date format: %v", err))
+		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
-	end, err := time.ParseInLocation("2006-01-02", endStr, h.timezone)
+	response := map[string]interface{}{
+		"calendars": s.syncStatuses(a),
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// syncStatuses returns the sync state of the calendars a user may see, under
+// their own names
+func (s *state) syncStatuses(a access) []cache.CalendarStatus {
+	statuses := []cache.CalendarStatus{}
+	for _, status := range s.cache.Status() {
+		if a.allows(status.Name) {
+			status.Name = a.calendarName(status.Name)
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// GetEvents handles the events endpoint, showing the calendars the user may
+// see, or those of the view selected with the view query parameter
+func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	start, end, err := s.parseDateRange(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid end date format: %v", err))
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Add one day to end to make it inclusive
-	end = end.Add(24 * time.Hour)
+	sources, a, ok := s.visibleSources(w, r)
+	if !ok {
+		return
+	}
 
 	// Fetch events from all calendars in parallel
 	var (
 		allEvents []*caldav.Event
-		mu        sync.Mutex
-		wg        sync.WaitGroup
-		errs      []error
+		failed    failures
 	)
+	for _, result := range s.fetchEvents(r.Context(), sources, start, end) {
+		if result.err != nil {
+			failed.add(r, a, result.source, result.err)
+			continue
+		}
 
-	for _, client := range h.clients {
-		wg.Add(1)
-		go func(c *caldav.Client) {
-			defer wg.Done()
-
-			events, err := c.FetchEvents(start, end)
-			if err != nil {
-				mu.Lock()
-				errs = append(errs, fmt.Errorf("calendar %s: %w", c.GetCalendarName(), err))
-				mu.Unlock()
-				return
-			}
-
-			mu.Lock()
-			allEvents = append(allEvents, events...)
-			mu.Unlock()
-		}(client)
+		calendar := result.source.GetCalendar()
+		for _, event := range result.events {
+			redactEvent(event, a.privacyOf(calendar))
+		}
+		a.presentEvents(calendar, result.events)
+		allEvents = append(allEvents, result.events...)
 	}
 
-	wg.Wait()
-
-	// If all calendars failed, return error
-	if len(errs) > 0 && len(allEvents) == 0 {
-		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to fetch events: %v", errs))
+	// If all calendars failed, return error; otherwise report the failed
+	// ones along with the events of the others
+	if failed.all(len(sources)) {
+		failed.writeError(w, "events")
 		return
 	}
 
-	// If some calendars failed, log but continue
-	if len(errs) > 0 {
-		for _, err := range errs {
-			fmt.Fprintf(os.Stderr, "Error fetching events: %v\n", err)
-		}
-	}
-
 	// Sort events
 	sort.Sort(caldav.Events(allEvents))
 
 	response := map[string]interface{}{
 		"events": allEvents,
 	}
+	failed.report(response)
 	writeJSON(w, http.StatusOK, response)
 }
 
+// sourceEvents holds the events of a calendar, or the error fetching them
+type sourceEvents struct {
+	source caldav.Source
+	events []*caldav.Event
+	err    error
+}
+
+// fetchEvents fetches the events of the given calendars in parallel,
+// returning the results in the order of the calendars
+func (s *state) fetchEvents(ctx context.Context, sources []caldav.Source, start, end time.Time) []sourceEvents {
+	results := make([]sourceEvents, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, c caldav.Source) {
+			defer wg.Done()
+
+			events, err := s.cache.Events(ctx, c, start, end)
+			results[i] = sourceEvents{source: c, events: events, err: err}
+		}(i, source)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// parseDateRange parses the required start and end query parameters
+// (YYYY-MM-DD), with end inclusive
+func (s *state) parseDateRange(r *http.Request) (time.Time, time.Time, error) {
+	startStr := r.URL.Query().Get("start")
+	endStr := r.URL.Query().Get("end")
+
+	if startStr == "" || endStr == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("start and end query parameters are required (format: YYYY-MM-DD)")
+	}
+
+	// Parse dates
+	start, err := time.ParseInLocation("2006-01-02", startStr, s.timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start date format: %v", err)
+	}
+
+	end, err := time.ParseInLocation("2006-01-02", endStr, s.timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end date format: %v", err)
+	}
+
+	// Add one day to end to make it inclusive
+	return start, end.Add(24 * time.Hour), nil
+}
+
 // GetEventsMonth handles the month events endpoint
-// Returns days that have events in the specified month
+// Returns days that have events in the specified month, in the calendars the
+// user may see or those of the selected view
 func (h *Handler) GetEventsMonth(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
 	// Parse query parameters
 	yearStr := r.URL.Query().Get("year")
 	monthStr := r.URL.Query().Get("month")
@@ -184,48 +371,43 @@ func (h *Handler) GetEventsMonth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sources, a, ok := s.visibleSources(w, r)
+	if !ok {
+		return
+	}
+
 	// Calculate start and end of month
-	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, h.timezone)
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, s.timezone)
 	end := start.AddDate(0, 1, 0) // First day of next month
 
 	// Fetch events from all calendars in parallel
 	var (
 		allEvents []*caldav.Event
-		mu        sync.Mutex
-		wg        sync.WaitGroup
+		failed    failures
 	)
-
-	for _, client := range h.clients {
-		wg.Add(1)
-		go func(c *caldav.Client) {
-			defer wg.Done()
-
-			events, err := c.FetchEvents(start, end)
-			if err != nil {
-				// Log but continue
-				fmt.Fprintf(os.Stderr, "Error fetching events for month view: %v\n", err)
-				return
-			}
-
-			mu.Lock()
-			allEvents = append(allEvents, events...)
-			mu.Unlock()
-		}(client)
+	for _, result := range s.fetchEvents(r.Context(), sources, start, end) {
+		if result.err != nil {
+			failed.add(r, a, result.source, result.err)
+			continue
+		}
+		allEvents = append(allEvents, result.events...)
+	}
+	if failed.all(len(sources)) {
+		failed.writeError(w, "events")
+		return
 	}
-
-	wg.Wait()
 
 	// Extract unique days
 	daysSet := make(map[int]bool)
 	for _, event := range allEvents {
 		// Get the day of month for the event start
-		day := event.Start.In(h.timezone).Day()
+		day := event.Start.In(s.timezone).Day()
 		daysSet[day] = true
 
 		// If event spans multiple days, mark all days
 		if !event.AllDay {
-			eventEnd := event.End.In(h.timezone)
-			for d := event.Start.In(h.timezone); d.Before(eventEnd) && d.Month() == time.Month(month); d = d.Add(24 * time.Hour) {
+			eventEnd := event.End.In(s.timezone)
+			for d := event.Start.In(s.timezone); d.Before(eventEnd) && d.Month() == time.Month(month); d = d.Add(24 * time.Hour) {
 				daysSet[d.Day()] = true
 			}
 		}
@@ -240,6 +422,98 @@ func (h *Handler) GetEventsMonth(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"days": days,
 	}
+	failed.report(response)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetTasks handles the tasks endpoint. Open tasks whose due date has passed
+// are carried forward to today, when today is within the requested range.
+// Completed and cancelled tasks are left out unless completed=true. Like
+// events, tasks are restricted to the calendars the user may see.
+func (h *Handler) GetTasks(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	includeCompleted := r.URL.Query().Get("completed") == "true"
+
+	start, end, err := s.parseDateRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sources, a, ok := s.visibleSources(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now().In(s.timezone)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.timezone)
+	showOverdue := !today.Before(start) && today.Before(end)
+
+	// Collect tasks from all calendars in parallel
+	var (
+		allTasks []*caldav.Task
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failed   failures
+	)
+
+	for _, source := range sources {
+		wg.Add(1)
+		go func(c caldav.Source) {
+			defer wg.Done()
+
+			objects, err := s.cache.Objects(r.Context(), c)
+			if err != nil {
+				failed.add(r, a, c, err)
+				return
+			}
+
+			privacy := a.privacyOf(c.GetCalendar())
+			var tasks []*caldav.Task
+			for _, task := range c.ParseTasks(r.Context(), objects) {
+				if !task.IsOpen() && !includeCompleted {
+					continue
+				}
+				redactTask(task, privacy)
+
+				// Overdue tasks are only shown on today
+				if task.IsOpen() && task.Due != nil && task.Due.Before(today) {
+					if showOverdue {
+						task.Overdue = true
+						tasks = append(tasks, task)
+					}
+					continue
+				}
+
+				if task.InRange(start, end) {
+					tasks = append(tasks, task)
+				}
+			}
+
+			a.presentTasks(c.GetCalendar(), tasks)
+
+			mu.Lock()
+			allTasks = append(allTasks, tasks...)
+			mu.Unlock()
+		}(source)
+	}
+
+	wg.Wait()
+
+	// If all calendars failed, return error; otherwise report the failed
+	// ones along with the tasks of the others
+	if failed.all(len(sources)) {
+		failed.writeError(w, "tasks")
+		return
+	}
+
+	// Sort tasks
+	sort.Sort(caldav.Tasks(allTasks))
+
+	response := map[string]interface{}{
+		"tasks": allTasks,
+	}
+	failed.report(response)
 	writeJSON(w, http.StatusOK, response)
 }
 
@@ -248,7 +522,7 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		fmt.Fprintf(os.Stderr, "Error encoding JSON response: %v\n", err)
+		slog.Error("failed to encode JSON response", "error", err)
 	}
 }
 
@@ -261,6 +535,6 @@ func writeError(w http.ResponseWriter, status int, message string) {
 		"code":  status,
 	}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		fmt.Fprintf(os.Stderr, "Error encoding error response: %v\n", err)
+		slog.Error("failed to encode error response", "error", err)
 	}
 }
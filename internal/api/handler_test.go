@@ -0,0 +1,383 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mano/mucal/internal/caldavtest"
+	"github.com/mano/mucal/internal/config"
+)
+
+// testEvent holds the fields of an event the tests assert on
+type testEvent struct {
+	Summary  string `json:"summary"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	AllDay   bool   `json:"allDay"`
+	Calendar string `json:"calendarName"`
+}
+
+// newTestServer starts a CalDAV server seeded with the fixtures
+func newTestServer(t *testing.T) *caldavtest.Server {
+	t.Helper()
+	return caldavtest.NewServer(t, filepath.Join("testdata", "calendars"))
+}
+
+// newTestHandler creates a handler showing the given calendars of the
+// server in Europe/Rome, stopped when the test ends
+func newTestHandler(t *testing.T, server *caldavtest.Server, calendars ...string) http.Handler {
+	t.Helper()
+
+	var cals []config.Calendar
+	for _, name := range calendars {
+		cals = append(cals, testCalendar(t, server, name))
+	}
+	return newConfiguredHandler(t, cals...)
+}
+
+// testCalendar returns the configuration of a calendar of the server
+func testCalendar(t *testing.T, server *caldavtest.Server, name string) config.Calendar {
+	t.Helper()
+
+	passwordFile := filepath.Join(t.TempDir(), "password.txt")
+	if err := os.WriteFile(passwordFile, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return config.Calendar{
+		Name:         name,
+		URL:          server.URL(name),
+		UserID:       "user",
+		PasswordFile: passwordFile,
+		Color:        "#4ECDC4",
+	}
+}
+
+// newConfiguredHandler creates a handler showing the given calendars in
+// Europe/Rome, stopped when the test ends
+func newConfiguredHandler(t *testing.T, calendars ...config.Calendar) http.Handler {
+	t.Helper()
+
+	return newHandlerFor(t, &config.Config{
+		TimeZone:     "Europe/Rome",
+		AutoRefresh:  60,
+		SyncInterval: 3600,
+		Calendars:    calendars,
+	})
+}
+
+// newHandlerFor creates the handler of a configuration, behind its
+// authentication and metrics, stopped when the test ends
+func newHandlerFor(t *testing.T, cfg *config.Config) http.Handler {
+	t.Helper()
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	t.Cleanup(handler.Close)
+
+	mux := http.NewServeMux()
+	handler.SetupRoutes(mux)
+	return handler.MetricsMiddleware(handler.AuthMiddleware(mux))
+}
+
+// get performs a GET request, decoding the JSON response into v
+func get(t *testing.T, h http.Handler, target string, v any) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: invalid JSON %q: %v", target, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// getEvents returns the events of the given date range
+func getEvents(t *testing.T, h http.Handler, start, end string) (int, []testEvent) {
+	t.Helper()
+
+	var response struct {
+		Events []testEvent `json:"events"`
+	}
+	code := get(t, h, "/api/events?start="+start+"&end="+end, &response)
+	return code, response.Events
+}
+
+func TestEventsRecurringWithOverrides(t *testing.T) {
+	h := newTestHandler(t, newTestServer(t), "personal")
+
+	code, events := getEvents(t, h, "2026-10-05", "2026-10-18")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+
+	// The Wednesday occurrence is excluded by EXDATE and the second Monday
+	// is moved by its RECURRENCE-ID override
+	want := []testEvent{
+		{Summary: "Long weekend, off", Start: "2026-10-08T00:00:00+02:00", End: "2026-10-10T00:00:00+02:00", AllDay: true, Calendar: "personal"},
+		{Summary: "Standup", Start: "2026-10-05T09:00:00+02:00", End: "2026-10-05T09:15:00+02:00", Calendar: "personal"},
+		{Summary: "Standup (moved)", Start: "2026-10-12T11:30:00+02:00", End: "2026-10-12T12:00:00+02:00", Calendar: "personal"},
+		{Summary: "Standup", Start: "2026-10-14T09:00:00+02:00", End: "2026-10-14T09:15:00+02:00", Calendar: "personal"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events =\n%+v\nwant\n%+v", events, want)
+	}
+}
+
+func TestEventsDetails(t *testing.T) {
+	h := newTestHandler(t, newTestServer(t), "personal")
+
+	type attendee struct {
+		Name   string `json:"name"`
+		Email  string `json:"email"`
+		Role   string `json:"role"`
+		Status string `json:"status"`
+	}
+	type details struct {
+		Summary      string     `json:"summary"`
+		Status       string     `json:"status"`
+		Transparency string     `json:"transparency"`
+		Class        string     `json:"class"`
+		Categories   []string   `json:"categories"`
+		URL          string     `json:"url"`
+		Priority     int        `json:"priority"`
+		Organizer    *attendee  `json:"organizer"`
+		Attendees    []attendee `json:"attendees"`
+	}
+	var response struct {
+		Events []details `json:"events"`
+	}
+	if code := get(t, h, "/api/events?start=2026-10-05&end=2026-10-13", &response); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+
+	standup := details{
+		Summary:      "Standup",
+		Status:       "CONFIRMED",
+		Transparency: "OPAQUE",
+		Class:        "PUBLIC",
+		Categories:   []string{"Team", "Daily, short"},
+		URL:          "https://meet.example.com/standup",
+		Priority:     5,
+		Organizer:    &attendee{Name: "Ada Lovelace", Email: "ada@example.com"},
+		Attendees: []attendee{
+			{Name: "Bob", Email: "bob@example.com", Role: "REQ-PARTICIPANT", Status: "ACCEPTED"},
+			{Email: "carol@example.com", Role: "OPT-PARTICIPANT", Status: "TENTATIVE"},
+		},
+	}
+
+	// Overrides carry their own details
+	want := []details{
+		{Summary: "Long weekend, off"},
+		standup,
+		{Summary: "Standup (moved)", Status: "TENTATIVE"},
+	}
+	if !reflect.DeepEqual(response.Events, want) {
+		t.Errorf("events =\n%+v\nwant\n%+v", response.Events, want)
+	}
+}
+
+func TestEventsAcrossDSTTransition(t *testing.T) {
+	h := newTestHandler(t, newTestServer(t), "work")
+
+	code, events := getEvents(t, h, "2026-10-19", "2026-11-08")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+
+	// DST ends on October 25 in Rome and on November 1 in New York.
+	// Recurring events keep the wall-clock time of their own time zone
+	// (or of the configured one when floating), UTC events keep their
+	// instant, and all-day events span local midnights.
+	want := []testEvent{
+		{Summary: "Offsite", Start: "2026-10-24T00:00:00+02:00", End: "2026-10-27T00:00:00+01:00", AllDay: true, Calendar: "work"},
+		{Summary: "On call", Start: "2026-10-24T00:00:00+02:00", End: "2026-10-25T00:00:00+02:00", AllDay: true, Calendar: "work"},
+		{Summary: "On call", Start: "2026-10-25T00:00:00+02:00", End: "2026-10-26T00:00:00+01:00", AllDay: true, Calendar: "work"},
+		{Summary: "On call", Start: "2026-10-26T00:00:00+01:00", End: "2026-10-27T00:00:00+01:00", AllDay: true, Calendar: "work"},
+		{Summary: "Weekly sync", Start: "2026-10-19T09:00:00+02:00", End: "2026-10-19T09:30:00+02:00", Calendar: "work"},
+		{Summary: "Review", Start: "2026-10-21T17:00:00+02:00", End: "2026-10-21T18:00:00+02:00", Calendar: "work"},
+		{Summary: "Partners call", Start: "2026-10-22T16:00:00+02:00", End: "2026-10-22T17:00:00+02:00", Calendar: "work"},
+		{Summary: "Run", Start: "2026-10-24T08:00:00+02:00", End: "2026-10-24T09:00:00+02:00", Calendar: "work"},
+		{Summary: "Run", Start: "2026-10-25T08:00:00+01:00", End: "2026-10-25T09:00:00+01:00", Calendar: "work"},
+		{Summary: "Weekly sync", Start: "2026-10-26T09:00:00+01:00", End: "2026-10-26T09:30:00+01:00", Calendar: "work"},
+		{Summary: "Review", Start: "2026-10-28T16:00:00+01:00", End: "2026-10-28T17:00:00+01:00", Calendar: "work"},
+		{Summary: "Partners call", Start: "2026-10-29T15:00:00+01:00", End: "2026-10-29T16:00:00+01:00", Calendar: "work"},
+		{Summary: "Weekly sync", Start: "2026-11-02T09:00:00+01:00", End: "2026-11-02T09:30:00+01:00", Calendar: "work"},
+		{Summary: "Partners call", Start: "2026-11-05T16:00:00+01:00", End: "2026-11-05T17:00:00+01:00", Calendar: "work"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events =\n%+v\nwant\n%+v", events, want)
+	}
+}
+
+func TestEventsPartialFailure(t *testing.T) {
+	server := newTestServer(t)
+	server.Fail("work", http.StatusInternalServerError)
+	h := newTestHandler(t, server, "personal", "work")
+
+	code, events := getEvents(t, h, "2026-10-05", "2026-10-11")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	for _, event := range events {
+		if event.Calendar != "personal" {
+			t.Errorf("unexpected event %+v from failing calendar", event)
+		}
+	}
+	if len(events) != 2 {
+		t.Errorf("got %d events, want the 2 of the working calendar", len(events))
+	}
+
+	// The failing calendar is reported alongside the others
+	for _, target := range []string{
+		"/api/events?start=2026-10-05&end=2026-10-11",
+		"/api/events/month?year=2026&month=10",
+		"/api/tasks?start=2026-10-05&end=2026-10-11",
+		"/api/freebusy?start=2026-10-05&end=2026-10-11",
+	} {
+		var response struct {
+			Warnings []calendarWarning `json:"warnings"`
+		}
+		if code := get(t, h, target, &response); code != http.StatusOK {
+			t.Errorf("GET %s: status = %d, want %d", target, code, http.StatusOK)
+			continue
+		}
+		if len(response.Warnings) != 1 {
+			t.Errorf("GET %s: warnings = %+v, want one", target, response.Warnings)
+			continue
+		}
+		if w := response.Warnings[0]; w.Calendar != "work" || w.Cause != "other" || !strings.Contains(w.Message, "500") {
+			t.Errorf("GET %s: warning = %+v", target, w)
+		}
+	}
+
+	// Once every calendar fails, so does the request
+	server.Fail("personal", http.StatusInternalServerError)
+	h = newTestHandler(t, server, "personal", "work")
+
+	if code, _ := getEvents(t, h, "2026-10-05", "2026-10-11"); code != http.StatusInternalServerError {
+		t.Errorf("status with all calendars failing = %d, want %d", code, http.StatusInternalServerError)
+	}
+}
+
+// testTask returns a calendar holding a task due on the given date, with
+// the given extra properties
+func testTask(uid, summary string, due time.Time, props string) string {
+	return "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//mucal//tests//EN\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:" + uid + "\r\n" +
+		"DTSTAMP:20260901T000000Z\r\n" +
+		"DUE;VALUE=DATE:" + due.Format("20060102") + "\r\n" +
+		"SUMMARY:" + summary + "\r\n" +
+		props +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+}
+
+func TestTasks(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().In(rome)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, rome)
+	lastWeek := today.AddDate(0, 0, -7)
+
+	server := caldavtest.NewServer(t, "")
+	for file, data := range map[string]string{
+		"today.ics":    testTask("today", "Today", today, ""),
+		"overdue.ics":  testTask("overdue", "Overdue", lastWeek, ""),
+		"done.ics":     testTask("done", "Done", today, "STATUS:COMPLETED\r\nCOMPLETED:"+now.UTC().Format("20060102T150405Z")+"\r\n"),
+		"finished.ics": testTask("finished", "Finished late", lastWeek, "PERCENT-COMPLETE:100\r\n"),
+	} {
+		if err := server.Put("tasks", file, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := newTestHandler(t, server, "tasks")
+
+	type task struct {
+		Summary string `json:"summary"`
+		Overdue bool   `json:"overdue"`
+	}
+	date := func(t time.Time) string { return t.Format("2006-01-02") }
+
+	tests := []struct {
+		name  string
+		query string
+		want  []task
+	}{
+		{
+			// Open overdue tasks are carried forward to today, ahead of
+			// the tasks due today, and completed ones are hidden
+			name:  "today",
+			query: "start=" + date(today) + "&end=" + date(today.AddDate(0, 0, 1)),
+			want:  []task{{Summary: "Overdue", Overdue: true}, {Summary: "Today"}},
+		},
+		{
+			name:  "today with completed",
+			query: "start=" + date(today) + "&end=" + date(today.AddDate(0, 0, 1)) + "&completed=true",
+			want:  []task{{Summary: "Overdue", Overdue: true}, {Summary: "Done"}, {Summary: "Today"}},
+		},
+		{
+			// Completed tasks stay on their own date
+			name:  "last week with completed",
+			query: "start=" + date(lastWeek) + "&end=" + date(lastWeek.AddDate(0, 0, 1)) + "&completed=true",
+			want:  []task{{Summary: "Finished late"}},
+		},
+		{
+			name:  "last week",
+			query: "start=" + date(lastWeek) + "&end=" + date(lastWeek.AddDate(0, 0, 1)),
+			want:  []task{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response struct {
+				Tasks []task `json:"tasks"`
+			}
+			if code := get(t, h, "/api/tasks?"+tt.query, &response); code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", code, http.StatusOK)
+			}
+			if response.Tasks == nil {
+				response.Tasks = []task{}
+			}
+			if !reflect.DeepEqual(response.Tasks, tt.want) {
+				t.Errorf("tasks = %+v, want %+v", response.Tasks, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mano/mucal/internal/logging"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var seen string
+	h := LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		generate bool
+	}{
+		{name: "given", header: "client-42"},
+		{name: "missing", generate: true},
+		{name: "invalid", header: "bad id\n", generate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/s/secret-token/api/events", nil)
+			if tt.header != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(logging.RequestIDHeader)
+			if id == "" || (!tt.generate && id != tt.header) || (tt.generate && id == tt.header) {
+				t.Errorf("response request ID = %q", id)
+			}
+			if seen != id {
+				t.Errorf("handler request ID = %q, want %q", seen, id)
+			}
+
+			var entry struct {
+				Msg       string `json:"msg"`
+				Method    string `json:"method"`
+				Path      string `json:"path"`
+				Status    int    `json:"status"`
+				RequestID string `json:"request_id"`
+			}
+			if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid log %q: %v", logs.String(), err)
+			}
+			if entry.Msg != "request" || entry.Method != http.MethodGet || entry.Status != http.StatusTeapot || entry.RequestID != id {
+				t.Errorf("log entry = %+v", entry)
+			}
+			// Share tokens are secrets
+			if entry.Path != "/s/…/api/events" {
+				t.Errorf("logged path = %q, want the share token redacted", entry.Path)
+			}
+		})
+	}
+}
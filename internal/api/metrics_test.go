@@ -0,0 +1,77 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	server := newTestServer(t)
+	server.Fail("work", http.StatusUnauthorized)
+	h := newTestHandler(t, server, "personal", "work")
+
+	if code, _ := getEvents(t, h, "2026-10-05", "2026-10-11"); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	// Metrics accumulate over the tests, so only their presence is checked
+	for _, want := range []string{
+		`mucal_http_requests_total{route="/api/events",method="GET",status="200"} `,
+		`mucal_http_request_duration_seconds_count{route="/api/events"} `,
+		`mucal_calendar_fetch_duration_seconds_count{calendar="personal"} `,
+		`mucal_calendar_fetch_errors_total{calendar="work",cause="auth"} `,
+		`mucal_cache_requests_total{calendar="personal",result="`,
+		`mucal_events_parsed_total{calendar="personal"} `,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestMetricsReload(t *testing.T) {
+	server := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, server, "#4ECDC4", "personal", "work")
+	handler, h := newReloadHandler(t, path)
+
+	if code, _ := getEvents(t, h, "2026-10-05", "2026-10-11"); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+
+	// The series of a removed calendar go away with it
+	writeConfig(t, path, server, "#4ECDC4", "personal")
+	if err := handler.Reload(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	body := serve(h, httptest.NewRequest(http.MethodGet, "/metrics", nil)).Body.String()
+	if strings.Contains(body, `calendar="work"`) {
+		t.Errorf("metrics keep the series of the removed calendar")
+	}
+	if !strings.Contains(body, `mucal_calendar_fetch_duration_seconds_count{calendar="personal"} `) {
+		t.Errorf("metrics lack the series of the remaining calendar")
+	}
+}
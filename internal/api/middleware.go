@@ -15,38 +15,117 @@
 package api
 
 import (
-	"fmt"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
+
+	"github.com/mano/mucal/internal/logging"
+	"github.com/mano/mucal/internal/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec("mucal_http_requests_total",
+		"HTTP requests, by route, method and status.", "route", "method", "status")
+	httpDuration = metrics.NewHistogramVec("mucal_http_request_duration_seconds",
+		"Duration of HTTP requests, by route.", metrics.DefaultBuckets, "route")
 )
 
-// LoggingMiddleware logs HTTP requests
+// LoggingMiddleware logs HTTP requests. Each request gets an ID, taken from
+// its X-Request-ID header when valid, which is returned in the response,
+// logged with every message of the request and sent along with its CalDAV
+// requests.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		id := r.Header.Get(logging.RequestIDHeader)
+		if !validRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(logging.RequestIDHeader, id)
+		ctx := logging.WithRequestID(r.Context(), id)
+
 		// Create a response writer wrapper to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
-		next.ServeHTTP(wrapped, r)
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
 
-		duration := time.Since(start)
-		fmt.Fprintf(os.Stderr, "%s %s %d %s\n",
-			r.Method,
-			r.URL.Path,
-			wrapped.statusCode,
-			duration,
+		logging.FromContext(ctx).Info("request",
+			"method", r.Method,
+			"path", redactSharePath(r.URL.Path),
+			"status", wrapped.statusCode,
+			"duration_ms", time.Since(start).Milliseconds(),
 		)
 	})
 }
 
+// validRequestID reports whether a request ID received from a client is
+// short and made of safe characters, so that it can be logged and forwarded
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// MetricsMiddleware counts HTTP requests and measures their duration, by
+// the route serving them
+func (h *Handler) MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := h.routeOf(r)
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+
+		httpRequests.Inc(route, metricsMethod(r.Method), strconv.Itoa(wrapped.statusCode))
+		httpDuration.Observe(time.Since(start).Seconds(), route)
+	})
+}
+
+// routeOf returns the pattern of the route serving a request, share links
+// being served by the route of the path they lead to
+func (h *Handler) routeOf(r *http.Request) string {
+	if h.mux == nil {
+		return "unknown"
+	}
+
+	_, path := shareToken(r)
+	req := *r
+	u := *r.URL
+	u.Path, u.RawPath = path, ""
+	req.URL = &u
+
+	if _, pattern := h.mux.Handler(&req); pattern != "" {
+		return pattern
+	}
+	return "unknown"
+}
+
+// metricsMethod returns the method of a request as a metric label, folding
+// unusual methods together
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "other"
+}
+
 // RecoveryMiddleware recovers from panics and returns 500 error
 func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				fmt.Fprintf(os.Stderr, "Panic recovered: %v\n", err)
+				logging.FromContext(r.Context()).Error("panic recovered", "panic", err)
 				writeError(w, http.StatusInternalServerError, "Internal server error")
 			}
 		}()
@@ -81,3 +160,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap exposes the wrapped writer to http.ResponseController, which
+// streaming handlers use to flush and extend deadlines
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"strings"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/caldav"
+	"github.com/mano/mucal/internal/config"
+)
+
+// busySummary replaces the title of events shown as busy blocks only
+const busySummary = "Busy"
+
+// privacyRank orders privacy levels from the least to the most restrictive
+var privacyRank = map[string]int{
+	config.PrivacyFull:      0,
+	config.PrivacyTitleOnly: 1,
+	config.PrivacyBusy:      2,
+}
+
+// stricterPrivacy returns the more restrictive of two privacy levels
+func stricterPrivacy(a, b string) string {
+	if privacyRank[b] > privacyRank[a] {
+		return b
+	}
+	return a
+}
+
+// itemPrivacy returns the privacy level of an event or task of a calendar.
+// Items marked CLASS:PRIVATE or CLASS:CONFIDENTIAL are shown as busy
+// blocks, whatever the level of their calendar.
+func itemPrivacy(privacy, class string) string {
+	switch strings.ToUpper(class) {
+	case "PRIVATE", "CONFIDENTIAL":
+		return config.PrivacyBusy
+	}
+	return privacy
+}
+
+// redactEvent removes from an event the fields its privacy level hides
+func redactEvent(event *caldav.Event, privacy string) {
+	switch itemPrivacy(privacy, event.Class) {
+	case config.PrivacyBusy:
+		event.Summary = busySummary
+		fallthrough
+	case config.PrivacyTitleOnly:
+		event.Description = ""
+		event.Location = ""
+		event.Categories = nil
+		event.URL = ""
+		event.Organizer = nil
+		event.Attendees = nil
+	}
+}
+
+// redactTask removes from a task the fields its privacy level hides
+func redactTask(task *caldav.Task, privacy string) {
+	switch itemPrivacy(privacy, task.Class) {
+	case config.PrivacyBusy:
+		task.Summary = busySummary
+		fallthrough
+	case config.PrivacyTitleOnly:
+		task.Description = ""
+	}
+}
+
+// redactedProps lists the properties of events and tasks kept by the
+// title-only and busy privacy levels: their identity, times, recurrence and
+// status. Busy items get a placeholder SUMMARY.
+var redactedProps = map[string]bool{
+	ical.PropUID:             true,
+	ical.PropDateTimeStamp:   true,
+	ical.PropDateTimeStart:   true,
+	ical.PropDateTimeEnd:     true,
+	ical.PropDue:             true,
+	ical.PropDuration:        true,
+	ical.PropRecurrenceRule:  true,
+	ical.PropRecurrenceDates: true,
+	ical.PropExceptionDates:  true,
+	ical.PropRecurrenceID:    true,
+	ical.PropSequence:        true,
+	ical.PropStatus:          true,
+	ical.PropTransparency:    true,
+	ical.PropClass:           true,
+	ical.PropCompleted:       true,
+	ical.PropPercentComplete: true,
+	ical.PropCreated:         true,
+	ical.PropLastModified:    true,
+	ical.PropPriority:        true,
+}
+
+// redactObjects returns copies of calendar objects whose events and tasks
+// only keep the properties allowed by their privacy level. Components shown
+// in full are shared with the original objects.
+func redactObjects(objects []caldav.CalendarObject, privacy string) []caldav.CalendarObject {
+	redacted := make([]caldav.CalendarObject, len(objects))
+	for i, obj := range objects {
+		redacted[i] = obj
+		if obj.Data == nil {
+			continue
+		}
+
+		cal := &ical.Calendar{Component: &ical.Component{
+			Name:  obj.Data.Name,
+			Props: obj.Data.Props,
+		}}
+		for _, comp := range obj.Data.Children {
+			if comp.Name == ical.CompEvent || comp.Name == ical.CompToDo {
+				comp = redactComponent(comp, itemPrivacy(privacy, textValue(comp, ical.PropClass)))
+			}
+			cal.Children = append(cal.Children, comp)
+		}
+		redacted[i].Data = cal
+	}
+	return redacted
+}
+
+// redactComponent returns a copy of an event or task without the properties
+// and alarms hidden by a privacy level, or the component itself when shown
+// in full
+func redactComponent(comp *ical.Component, privacy string) *ical.Component {
+	if privacy == config.PrivacyFull {
+		return comp
+	}
+
+	props := make(ical.Props)
+	for name, values := range comp.Props {
+		if redactedProps[name] || (name == ical.PropSummary && privacy == config.PrivacyTitleOnly) {
+			props[name] = values
+		}
+	}
+	if privacy == config.PrivacyBusy {
+		props.SetText(ical.PropSummary, busySummary)
+	}
+
+	return &ical.Component{Name: comp.Name, Props: props}
+}
+
+// textValue returns the raw value of a property, or "" if absent
+func textValue(comp *ical.Component, name string) string {
+	if prop := comp.Props.Get(name); prop != nil {
+		return prop.Value
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mano/mucal/internal/config"
+)
+
+func TestEventsPrivacy(t *testing.T) {
+	type event struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Location    string `json:"location"`
+		URL         string `json:"url"`
+		Organizer   any    `json:"organizer"`
+		Attendees   []any  `json:"attendees"`
+	}
+
+	standup := event{Summary: "Standup", URL: "https://meet.example.com/standup"}
+	titleOnly := event{Summary: "Standup"}
+	busy := event{Summary: "Busy"}
+
+	tests := []struct {
+		privacy string
+		standup event
+	}{
+		{privacy: config.PrivacyFull, standup: standup},
+		{privacy: config.PrivacyTitleOnly, standup: titleOnly},
+		{privacy: config.PrivacyBusy, standup: busy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.privacy, func(t *testing.T) {
+			server := newTestServer(t)
+			personal := testCalendar(t, server, "personal")
+			personal.Privacy = tt.privacy
+			h := newConfiguredHandler(t, personal)
+
+			var response struct {
+				Events []event `json:"events"`
+			}
+			if code := get(t, h, "/api/events?start=2026-10-19&end=2026-10-20", &response); code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", code, http.StatusOK)
+			}
+			if len(response.Events) != 5 {
+				t.Fatalf("got %d events, want 5", len(response.Events))
+			}
+
+			first, last := response.Events[0], response.Events[len(response.Events)-1]
+			if tt.privacy == config.PrivacyFull {
+				// Compare the fields of interest only
+				if first.Organizer == nil || len(first.Attendees) != 2 {
+					t.Errorf("standup lacks its organizer or attendees: %+v", first)
+				}
+				first.Organizer, first.Attendees = nil, nil
+			}
+			if !reflect.DeepEqual(first, tt.standup) {
+				t.Errorf("standup = %+v, want %+v", first, tt.standup)
+			}
+
+			// Private events are busy blocks in every calendar
+			if !reflect.DeepEqual(last, busy) {
+				t.Errorf("private event = %+v, want %+v", last, busy)
+			}
+		})
+	}
+}
+
+func TestFeedPrivacy(t *testing.T) {
+	server := newTestServer(t)
+	personal := testCalendar(t, server, "personal")
+	personal.Privacy = config.PrivacyBusy
+	h := newConfiguredHandler(t, personal)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/calendars/personal.ics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{"SUMMARY:Busy", "RRULE:FREQ=WEEKLY", "RECURRENCE-ID;TZID=Europe/Rome:20261012T090000"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("feed lacks %q:\n%s", want, body)
+		}
+	}
+	for _, hidden := range []string{"Standup", "ada@example.com", "meet.example.com", "Via Roma", "VALARM"} {
+		if strings.Contains(body, hidden) {
+			t.Errorf("feed discloses %q:\n%s", hidden, body)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/mano/mucal/internal/cache"
+	"github.com/mano/mucal/internal/caldav"
+	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/metrics"
+)
+
+// discoveryTimeout bounds the discovery of the calendars of an account
+const discoveryTimeout = 30 * time.Second
+
+// state is what the handler builds from a configuration: the sources of its
+// calendars and their cache. A reload replaces it as a whole, and each
+// request works with the state it started with.
+type state struct {
+	config   *config.Config
+	sources  []caldav.Source
+	cache    *cache.Cache
+	timezone *time.Location
+
+	// discovery holds why the discovery of the calendars of an account
+	// failed, by account name
+	discovery map[string]error
+}
+
+// newState creates the sources of the calendars of a configuration, first
+// discovering those of its accounts, and a cache for them that is not
+// started yet. The previous state, if any, provides the calendars of the
+// accounts that cannot be discovered again.
+func newState(cfg *config.Config, previous *state, onChange func(cache.Change)) (*state, error) {
+	tz, err := cfg.GetLocation()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
+	}
+
+	discovery := discover(cfg, previous)
+
+	// Create a source for each calendar, according to its type
+	var sources []caldav.Source
+	for i := range cfg.Calendars {
+		source, err := caldav.NewSource(&cfg.Calendars[i], tz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create source for calendar %s: %w", cfg.Calendars[i].Name, err)
+		}
+		sources = append(sources, source)
+	}
+
+	return &state{
+		config:   cfg,
+		sources:  sources,
+		cache:    cache.New(sources, cfg.GetSyncInterval(), onChange),
+		timezone: tz,
+
+		discovery: discovery,
+	}, nil
+}
+
+// discover adds the calendars of the accounts of a configuration to those
+// it lists, so that they are listed like the configured ones, and returns
+// why discovery failed for the accounts it did. An account that cannot be
+// reached keeps the calendars previously discovered from it, if any, rather
+// than failing the whole configuration. Discovered calendars whose name is
+// already in use are left out.
+func discover(cfg *config.Config, previous *state) map[string]error {
+	failures := make(map[string]error)
+	names := make(map[string]bool)
+	for _, cal := range cfg.Calendars {
+		names[cal.Name] = true
+	}
+
+	for i := range cfg.Accounts {
+		acc := &cfg.Accounts[i]
+
+		ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+		discovered, err := caldav.DiscoverCalendars(ctx, acc)
+		cancel()
+		if err != nil {
+			discovered = previous.discovered(acc.Name)
+			slog.Error("failed to discover calendars", "account", acc.Name, "kept", len(discovered), "error", err)
+			failures[acc.Name] = err
+		}
+
+		for _, cal := range discovered {
+			if names[cal.Name] {
+				slog.Error("discovered calendar name already in use, leaving it out", "account", acc.Name, "calendar", cal.Name)
+				failures[acc.Name] = errors.Join(failures[acc.Name], fmt.Errorf("calendar name %q already in use", cal.Name))
+				continue
+			}
+			names[cal.Name] = true
+			cfg.Calendars = append(cfg.Calendars, cal)
+		}
+	}
+
+	return failures
+}
+
+// discovered returns the calendars discovered from an account, none if the
+// state is nil
+func (s *state) discovered(account string) []config.Calendar {
+	if s == nil {
+		return nil
+	}
+
+	var calendars []config.Calendar
+	for _, cal := range s.config.Calendars {
+		if cal.Account == account {
+			calendars = append(calendars, cal)
+		}
+	}
+	return calendars
+}
+
+// current returns the state of the configuration in use
+func (h *Handler) current() *state {
+	return h.state.Load()
+}
+
+// Config returns the configuration in use
+func (h *Handler) Config() *config.Config {
+	return h.current().config
+}
+
+// reloadStatus reports when the configuration in use was loaded, why the
+// last reload failed, if it did, and why the discovery of the calendars of
+// accounts failed, by account name
+type reloadStatus struct {
+	LoadedAt  time.Time         `json:"loadedAt"`
+	Error     string            `json:"error,omitempty"`
+	Discovery map[string]string `json:"discovery,omitempty"`
+}
+
+// lastReload returns the outcome of the last reload, along with the
+// discovery failures of the configuration in use
+func (h *Handler) lastReload() reloadStatus {
+	status := *h.reload.Load()
+	for account, err := range h.current().discovery {
+		if status.Discovery == nil {
+			status.Discovery = make(map[string]string)
+		}
+		status.Discovery[account] = err.Error()
+	}
+	return status
+}
+
+// Reload loads the configuration file at path again and, if it is valid,
+// switches to the calendars it configures. Requests in progress complete
+// with the previous calendars, whose sync is then stopped; calendars whose
+// source is unchanged are served from their previous objects until they
+// are synced again. On failure, the previous configuration stays in use and
+// the error is reported by the status endpoint. Changes to authentication
+// and to the share state file only apply on restart.
+func (h *Handler) Reload(path string) error {
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+
+	if h.closed {
+		return fmt.Errorf("handler is closed")
+	}
+	if err := h.swap(path); err != nil {
+		slog.Error("failed to reload configuration, keeping the previous one", "path", path, "error", err)
+		failed := *h.reload.Load()
+		failed.Error = err.Error()
+		h.reload.Store(&failed)
+		return err
+	}
+
+	slog.Info("reloaded configuration", "path", path, "calendars", len(h.current().config.Calendars))
+	h.reload.Store(&reloadStatus{LoadedAt: time.Now()})
+	return nil
+}
+
+// swap replaces the state with that of the configuration file at path
+func (h *Handler) swap(path string) error {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	old := h.current()
+	if !reflect.DeepEqual(cfg.Auth, old.config.Auth) || cfg.Shares.StateFile != old.config.Shares.StateFile {
+		slog.Warn("authentication and share state file changes apply on restart")
+		cfg.Auth = old.config.Auth
+		cfg.Shares.StateFile = old.config.Shares.StateFile
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	s, err := newState(cfg, old, h.broker.publish)
+	if err != nil {
+		return err
+	}
+	s.cache.Adopt(old.cache, func(a, b caldav.Source) bool {
+		return sameSource(a.GetCalendar(), b.GetCalendar())
+	})
+	s.cache.Start()
+
+	h.state.Store(s)
+	old.cache.Stop()
+
+	// Drop the metrics of removed calendars, once no sync may record them
+	kept := make(map[string]bool, len(s.sources))
+	for _, source := range s.sources {
+		kept[source.GetCalendarName()] = true
+	}
+	for _, source := range old.sources {
+		if !kept[source.GetCalendarName()] {
+			metrics.Delete("calendar", source.GetCalendarName())
+		}
+	}
+	return nil
+}
+
+// sameSource reports whether two calendar configurations read the same
+// calendar, although they may present it differently
+func sameSource(a, b *config.Calendar) bool {
+	return a.Name == b.Name && a.Type == b.Type && a.URL == b.URL && a.Path == b.Path && a.UserID == b.UserID
+}
@@ -0,0 +1,359 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mano/mucal/internal/caldavtest"
+	"github.com/mano/mucal/internal/config"
+)
+
+// writeConfig writes a configuration file showing the given calendars of
+// the server, the first one in the given color
+func writeConfig(t *testing.T, path string, server *caldavtest.Server, color string, calendars ...string) {
+	t.Helper()
+
+	var b strings.Builder
+	b.WriteString("time_zone: \"Europe/Rome\"\nauto_refresh: 60\nsync_interval: 3600\ncalendars:\n")
+	for i, name := range calendars {
+		cal := testCalendar(t, server, name)
+		if i == 0 {
+			cal.Color = color
+		}
+		fmt.Fprintf(&b, "  - name: %q\n    url: %q\n    user_id: %q\n    password_file: %q\n    color: %q\n",
+			cal.Name, cal.URL, cal.UserID, cal.PasswordFile, cal.Color)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newReloadHandler creates a handler from a configuration file, returning
+// it along with its routes
+func newReloadHandler(t *testing.T, path string) (*Handler, http.Handler) {
+	t.Helper()
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	t.Cleanup(handler.Close)
+
+	mux := http.NewServeMux()
+	handler.SetupRoutes(mux)
+	return handler, handler.AuthMiddleware(mux)
+}
+
+// calendarColors returns the colors of the calendars listed by the config
+// endpoint, by name
+func calendarColors(t *testing.T, h http.Handler) map[string]string {
+	t.Helper()
+
+	var response struct {
+		Calendars []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"calendars"`
+	}
+	if code := get(t, h, "/api/config", &response); code != http.StatusOK {
+		t.Fatalf("config status = %d, want %d", code, http.StatusOK)
+	}
+	colors := make(map[string]string)
+	for _, cal := range response.Calendars {
+		colors[cal.Name] = cal.Color
+	}
+	return colors
+}
+
+func TestReload(t *testing.T) {
+	server := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, server, "#4ECDC4", "personal")
+	handler, h := newReloadHandler(t, path)
+
+	// A changed color and an added calendar are picked up
+	writeConfig(t, path, server, "#FF6B6B", "personal", "work")
+	if err := handler.Reload(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	want := map[string]string{"personal": "#FF6B6B", "work": "#4ECDC4"}
+	if colors := calendarColors(t, h); !reflect.DeepEqual(colors, want) {
+		t.Errorf("calendars = %v, want %v", colors, want)
+	}
+
+	code, events := getEvents(t, h, "2026-10-19", "2026-10-20")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	found := false
+	for _, event := range events {
+		found = found || event.Calendar == "work"
+	}
+	if !found {
+		t.Errorf("events = %+v, want those of the added calendar too", events)
+	}
+}
+
+func TestReloadInvalid(t *testing.T) {
+	server := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, server, "#4ECDC4", "personal")
+	handler, h := newReloadHandler(t, path)
+
+	// An invalid configuration keeps the one in use
+	if err := os.WriteFile(path, []byte("auto_refresh: 60\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.Reload(path); err == nil {
+		t.Fatal("reload of an invalid configuration succeeded")
+	}
+
+	want := map[string]string{"personal": "#4ECDC4"}
+	if colors := calendarColors(t, h); !reflect.DeepEqual(colors, want) {
+		t.Errorf("calendars = %v, want %v", colors, want)
+	}
+
+	// and is reported until a valid one is loaded
+	var status struct {
+		Status string       `json:"status"`
+		Config reloadStatus `json:"config"`
+	}
+	get(t, h, "/api/status", &status)
+	if status.Status != "degraded" || !strings.Contains(status.Config.Error, "time_zone") {
+		t.Errorf("status = %q, config = %+v, want degraded with the error", status.Status, status.Config)
+	}
+
+	writeConfig(t, path, server, "#4ECDC4", "personal")
+	if err := handler.Reload(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	status.Config = reloadStatus{}
+	get(t, h, "/api/status", &status)
+	if status.Config.Error != "" {
+		t.Errorf("config error = %q after a valid reload", status.Config.Error)
+	}
+}
+
+// writeAccountConfig writes a configuration file showing the "personal"
+// calendar of the server, along with those discovered from an account
+func writeAccountConfig(t *testing.T, path string, server *caldavtest.Server, accountURL string) {
+	t.Helper()
+
+	cal := testCalendar(t, server, "personal")
+	data := fmt.Sprintf("time_zone: \"Europe/Rome\"\nauto_refresh: 60\nsync_interval: 3600\n"+
+		"calendars:\n  - name: %q\n    url: %q\n    user_id: %q\n    password_file: %q\n    color: %q\n"+
+		"accounts:\n  - name: \"server\"\n    url: %q\n    user_id: %q\n    password_file: %q\n",
+		cal.Name, cal.URL, cal.UserID, cal.PasswordFile, cal.Color, accountURL, cal.UserID, cal.PasswordFile)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// getReloadStatus returns the overall status and that of the configuration
+func getReloadStatus(t *testing.T, h http.Handler) (string, reloadStatus) {
+	t.Helper()
+
+	var status struct {
+		Status string       `json:"status"`
+		Config reloadStatus `json:"config"`
+	}
+	if code := get(t, h, "/api/status", &status); code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d", code, http.StatusOK)
+	}
+	return status.Status, status.Config
+}
+
+func TestDiscovery(t *testing.T) {
+	server := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeAccountConfig(t, path, server, server.Server.URL)
+	handler, h := newReloadHandler(t, path)
+
+	// The discovered calendar named like a configured one is left out
+	want := map[string]string{"personal": "#4ECDC4", "work": "#6C757D"}
+	if colors := calendarColors(t, h); !reflect.DeepEqual(colors, want) {
+		t.Errorf("calendars = %v, want %v", colors, want)
+	}
+	status, reload := getReloadStatus(t, h)
+	if status != "degraded" || !strings.Contains(reload.Discovery["server"], `"personal" already in use`) {
+		t.Errorf("status = %q, config = %+v, want degraded with the name collision", status, reload)
+	}
+
+	// An account that can no longer be reached keeps its calendars
+	writeAccountConfig(t, path, server, "http://127.0.0.1:1")
+	if err := handler.Reload(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if colors := calendarColors(t, h); !reflect.DeepEqual(colors, want) {
+		t.Errorf("calendars after failed discovery = %v, want %v", colors, want)
+	}
+	if _, reload := getReloadStatus(t, h); reload.Discovery["server"] == "" || reload.Error != "" {
+		t.Errorf("config = %+v, want the discovery error only", reload)
+	}
+}
+
+func TestDiscoveryUnreachable(t *testing.T) {
+	server := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeAccountConfig(t, path, server, "http://127.0.0.1:1")
+
+	// The configured calendars are shown without those of the account
+	_, h := newReloadHandler(t, path)
+
+	want := map[string]string{"personal": "#4ECDC4"}
+	if colors := calendarColors(t, h); !reflect.DeepEqual(colors, want) {
+		t.Errorf("calendars = %v, want %v", colors, want)
+	}
+	if status, reload := getReloadStatus(t, h); status != "degraded" || reload.Discovery["server"] == "" {
+		t.Errorf("status = %q, config = %+v, want degraded with the discovery error", status, reload)
+	}
+}
+
+func TestReloadStatusDuringDiscovery(t *testing.T) {
+	server := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, server, "#4ECDC4", "personal")
+	handler, h := newReloadHandler(t, path)
+
+	// An account server that answers once released
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(slow.Close)
+	var releaseOnce sync.Once
+	unblock := func() { releaseOnce.Do(func() { close(release) }) }
+	t.Cleanup(unblock)
+
+	writeAccountConfig(t, path, server, slow.URL)
+	reloaded := make(chan error, 1)
+	go func() { reloaded <- handler.Reload(path) }()
+	<-requested
+
+	// The status does not wait for the discovery in progress
+	answered := make(chan int, 1)
+	go func() {
+		answered <- serve(h, httptest.NewRequest(http.MethodGet, "/api/status", nil)).Code
+	}()
+	select {
+	case code := <-answered:
+		if code != http.StatusOK {
+			t.Errorf("status code during the reload = %d, want %d", code, http.StatusOK)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("status waits for the reload")
+	}
+
+	unblock()
+	if err := <-reloaded; err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+}
+
+func TestReloadKeepsSyncState(t *testing.T) {
+	// Count the full downloads of the calendar
+	server := newTestServer(t)
+	var downloads atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if r.Method == "REPORT" && bytes.Contains(body, []byte("calendar-query")) {
+			downloads.Add(1)
+		}
+		server.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	cal := testCalendar(t, server, "personal")
+	cal.URL = proxy.URL + strings.TrimPrefix(cal.URL, server.Server.URL)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(color string) {
+		data := fmt.Sprintf("time_zone: \"Europe/Rome\"\nauto_refresh: 60\nsync_interval: 3600\n"+
+			"calendars:\n  - name: %q\n    url: %q\n    user_id: %q\n    password_file: %q\n    color: %q\n",
+			cal.Name, cal.URL, cal.UserID, cal.PasswordFile, color)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("#4ECDC4")
+	handler, h := newReloadHandler(t, path)
+	if code, _ := getEvents(t, h, "2026-10-05", "2026-10-11"); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Fatalf("%d downloads before the reload, want 1", n)
+	}
+
+	lastAttempt := func() string {
+		var response struct {
+			Calendars []calendarStatus `json:"calendars"`
+		}
+		if code := get(t, h, "/api/sync", &response); code != http.StatusOK || len(response.Calendars) != 1 {
+			t.Fatalf("sync status: code %d, calendars %+v", code, response.Calendars)
+		}
+		return response.Calendars[0].LastAttempt
+	}
+	changes := handler.broker.subscribe(access{})
+	before := lastAttempt()
+
+	// A color change syncs the calendar again without downloading it
+	write("#FF6B6B")
+	if err := handler.Reload(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for lastAttempt() == before {
+		if time.Now().After(deadline) {
+			t.Fatal("calendar not synced after the reload")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("%d downloads after the reload, want 1", n)
+	}
+	select {
+	case data := <-changes:
+		t.Errorf("reload notified a change: %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
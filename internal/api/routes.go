@@ -16,13 +16,61 @@ package api
 
 import (
 	"net/http"
+	"strings"
+
+	"github.com/mano/mucal/internal/metrics"
 )
 
-// SetupRoutes sets up the HTTP routes
+// sharedRoutes are the API routes available with a share link, along with
+// the web UI. Share management and login are not.
+var sharedRoutes = map[string]bool{
+	"/api/health":       true,
+	"/api/health/ready": true,
+	"/api/status":       true,
+	"/api/config":       true,
+	"/api/sync":         true,
+	"/api/events":       true,
+	"/api/events/month": true,
+	"/api/tasks":        true,
+	"/api/freebusy":     true,
+	"/api/stream":       true,
+	"/api/calendar.ics": true,
+}
+
+// isSharedRoute reports whether a path may be requested with a share link
+func isSharedRoute(path string) bool {
+	switch {
+	case strings.HasPrefix(path, "/api/calendars/"):
+		return true
+	case strings.HasPrefix(path, "/api/"), strings.HasPrefix(path, "/auth/"), path == "/metrics":
+		return sharedRoutes[path]
+	default:
+		return true
+	}
+}
+
+// SetupRoutes sets up the HTTP routes. The mux is kept to label request
+// metrics with the route serving them.
 func (h *Handler) SetupRoutes(mux *http.ServeMux) {
+	h.mux = mux
+
 	// API routes
 	mux.HandleFunc("/api/health", h.Health)
+	mux.HandleFunc("/api/health/ready", h.Ready)
+	mux.HandleFunc("/api/status", h.GetStatus)
 	mux.HandleFunc("/api/config", h.GetConfig)
+	mux.HandleFunc("/api/sync", h.GetSyncStatus)
 	mux.HandleFunc("/api/events", h.GetEvents)
 	mux.HandleFunc("/api/events/month", h.GetEventsMonth)
+	mux.HandleFunc("/api/tasks", h.GetTasks)
+	mux.HandleFunc("/api/freebusy", h.GetFreeBusy)
+	mux.HandleFunc("/api/stream", h.Stream)
+	mux.HandleFunc("/api/calendar.ics", h.GetFeed)
+	mux.HandleFunc("/api/calendars/", h.GetCalendarFeed)
+	mux.HandleFunc("/api/shares", h.Shares)
+	mux.HandleFunc("/api/shares/", h.RevokeShare)
+	mux.Handle("/metrics", metrics.Handler())
+
+	// Login and logout
+	h.auth.setupRoutes(mux)
 }
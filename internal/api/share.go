@@ -0,0 +1,200 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mano/mucal/internal/share"
+)
+
+// sharePrefix starts the paths of share links, /s/{token}/...
+const sharePrefix = "/s/"
+
+// shareKey is the context key of the share link of a request
+type shareKey struct{}
+
+// shareFromContext returns the share link a request was made with, if any
+func shareFromContext(ctx context.Context) *share.Share {
+	sh, _ := ctx.Value(shareKey{}).(*share.Share)
+	return sh
+}
+
+// shareToken returns the share token of a request, given as /s/{token}/...
+// or with the token query parameter, along with the path it requests
+func shareToken(r *http.Request) (token, path string) {
+	if rest, ok := strings.CutPrefix(r.URL.Path, sharePrefix); ok {
+		token, path, _ = strings.Cut(rest, "/")
+		return token, "/" + path
+	}
+	return r.URL.Query().Get("token"), r.URL.Path
+}
+
+// shareMiddleware serves the requests made with a share link from next,
+// without authentication but within the scope of the share, and the other
+// requests from authenticated
+func (h *Handler) shareMiddleware(authenticated, next http.Handler) http.Handler {
+	if h.shares == nil {
+		return authenticated
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, path := shareToken(r)
+		if token == "" {
+			authenticated.ServeHTTP(w, r)
+			return
+		}
+
+		sh, ok := h.shares.Lookup(token)
+		if !ok {
+			writeError(w, http.StatusNotFound, "unknown, expired or revoked share link")
+			return
+		}
+
+		// The web UI loads its assets relative to the link
+		if r.URL.Path == sharePrefix+token {
+			http.Redirect(w, r, sharePrefix+token+"/", http.StatusMovedPermanently)
+			return
+		}
+		if !isSharedRoute(path) {
+			writeError(w, http.StatusForbidden, "not available with a share link")
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), shareKey{}, &sh))
+		r.URL.Path, r.URL.RawPath = path, ""
+		next.ServeHTTP(w, r)
+	})
+}
+
+// redactSharePath hides the token of a share link path, so that it is not
+// logged
+func redactSharePath(path string) string {
+	rest, ok := strings.CutPrefix(path, sharePrefix)
+	if !ok {
+		return path
+	}
+	if _, tail, found := strings.Cut(rest, "/"); found {
+		return sharePrefix + "…/" + tail
+	}
+	return sharePrefix + "…"
+}
+
+// shareRequest is the body of a share creation request
+type shareRequest struct {
+	Label     string     `json:"label"`
+	Calendars []string   `json:"calendars"`
+	Privacy   string     `json:"privacy"`
+	Expires   *time.Time `json:"expires"`
+}
+
+// Shares handles the share management endpoint, listing the share links
+// (GET) or creating one (POST, with a JSON shareRequest). Only the admins of
+// the configuration may use it.
+func (h *Handler) Shares(w http.ResponseWriter, r *http.Request) {
+	if !h.isShareAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		shares, err := h.shares.List()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if shares == nil {
+			shares = []share.Share{}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"shares": shares})
+
+	case http.MethodPost:
+		// Requiring JSON keeps cross-site forms from creating shares
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "request body must be JSON")
+			return
+		}
+		var req shareRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		if req.Expires != nil && !req.Expires.After(time.Now()) {
+			writeError(w, http.StatusBadRequest, "expires must be in the future")
+			return
+		}
+
+		sh, token, err := h.shares.Create(req.Label, req.Calendars, req.Privacy, req.Expires)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"share": sh,
+			"token": token,
+			"url":   sharePrefix + token + "/",
+		})
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// RevokeShare handles the share revocation endpoint, DELETE
+// /api/shares/{id}. Only the admins of the configuration may use it.
+func (h *Handler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	if !h.isShareAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "DELETE")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/shares/")
+	if err := h.shares.Revoke(id); errors.Is(err, share.ErrNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown share: %s", id))
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isShareAdmin checks that share links are enabled and that the user of a
+// request may manage them, writing an error response otherwise
+func (h *Handler) isShareAdmin(w http.ResponseWriter, r *http.Request) bool {
+	s := h.current()
+	if h.shares == nil {
+		writeError(w, http.StatusNotFound, "share links are not enabled")
+		return false
+	}
+	if !s.config.Shares.IsAdmin(UserFromContext(r.Context())) {
+		writeError(w, http.StatusForbidden, "only admins may manage share links")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mano/mucal/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newShareHandler creates a handler of the given calendars for local users,
+// where ada manages share links. It defaults to the personal and work
+// calendars.
+func newShareHandler(t *testing.T, calendars ...config.Calendar) http.Handler {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calendars) == 0 {
+		server := newTestServer(t)
+		calendars = []config.Calendar{testCalendar(t, server, "personal"), testCalendar(t, server, "work")}
+	}
+	return newHandlerFor(t, &config.Config{
+		TimeZone:     "Europe/Rome",
+		AutoRefresh:  60,
+		SyncInterval: 3600,
+		Calendars:    calendars,
+		Auth: config.Auth{
+			Mode: config.AuthLocal,
+			Users: []config.User{
+				{Username: "ada", PasswordHash: string(hash)},
+				{Username: "bob", PasswordHash: string(hash)},
+			},
+		},
+		Shares: config.Shares{
+			StateFile: filepath.Join(t.TempDir(), "shares.json"),
+			Admins:    []string{"ada"},
+		},
+	})
+}
+
+// createShare creates a share link as ada, returning its token
+func createShare(t *testing.T, h http.Handler, body string) string {
+	t.Helper()
+
+	rec := requestAs(h, "ada", http.MethodPost, "/api/shares", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	return created.Token
+}
+
+// requestAs performs a request as a local user, with an optional JSON body
+func requestAs(h http.Handler, user, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if user != "" {
+		r.SetBasicAuth(user, "secret")
+	}
+	return serve(h, r)
+}
+
+func TestShareLinks(t *testing.T) {
+	h := newShareHandler(t)
+
+	body := `{"label": "Contractors", "calendars": ["work"], "privacy": "busy"}`
+	assertError(t, requestAs(h, "bob", http.MethodPost, "/api/shares", body), http.StatusForbidden)
+
+	rec := requestAs(h, "ada", http.MethodPost, "/api/shares", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Share struct {
+			ID string `json:"id"`
+		} `json:"share"`
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.URL != "/s/"+created.Token+"/" {
+		t.Errorf("url = %q, want the link of token %q", created.URL, created.Token)
+	}
+
+	// The link shows the shared calendars as busy blocks, without login
+	for _, target := range []string{
+		"/s/" + created.Token + "/api/events?start=2026-10-05&end=2026-10-25",
+		"/api/events?start=2026-10-05&end=2026-10-25&token=" + created.Token,
+	} {
+		var response struct {
+			Events []testEvent `json:"events"`
+		}
+		if code := get(t, h, target, &response); code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", target, code, http.StatusOK)
+		}
+		if len(response.Events) == 0 {
+			t.Errorf("%s: no events", target)
+		}
+		for _, event := range response.Events {
+			if event.Calendar != "work" || event.Summary != busySummary {
+				t.Errorf("%s: event %+v, want busy blocks of work", target, event)
+			}
+		}
+	}
+
+	// Other calendars and routes are out of scope
+	link := "/s/" + created.Token
+	tests := []struct {
+		target string
+		status int
+	}{
+		{target: link, status: http.StatusMovedPermanently},
+		{target: link + "/api/calendars/work.ics", status: http.StatusOK},
+		{target: link + "/api/calendars/personal.ics", status: http.StatusNotFound},
+		{target: link + "/api/shares", status: http.StatusForbidden},
+		{target: link + "/auth/logout", status: http.StatusForbidden},
+		{target: "/s/wrong/api/events?start=2026-10-05&end=2026-10-25", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := serve(h, httptest.NewRequest(http.MethodGet, tt.target, nil)); rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+	}
+
+	// Revoked links stop working at once
+	if rec := requestAs(h, "ada", http.MethodDelete, "/api/shares/"+created.Share.ID, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	assertError(t, serve(h, httptest.NewRequest(http.MethodGet, link+"/api/config", nil)), http.StatusNotFound)
+	assertError(t, requestAs(h, "ada", http.MethodDelete, "/api/shares/"+created.Share.ID, ""), http.StatusNotFound)
+}
+
+func TestShareLinkWarnings(t *testing.T) {
+	// A private feed, with the secret in its URL, and a working calendar
+	feed := config.Calendar{Name: "Mano private", Type: config.TypeICS, URL: "http://127.0.0.1:1/feed.ics?key=s3cr3t", Color: "#FF6B6B"}
+	h := newShareHandler(t, feed, testCalendar(t, newTestServer(t), "work"))
+
+	for _, tt := range []struct {
+		calendars string
+		status    int
+	}{
+		{calendars: `["Mano private", "work"]`, status: http.StatusOK},
+		{calendars: `["Mano private"]`, status: http.StatusInternalServerError},
+	} {
+		token := createShare(t, h, `{"calendars": `+tt.calendars+`, "privacy": "busy"}`)
+		rec := serve(h, httptest.NewRequest(http.MethodGet, "/s/"+token+"/api/events?start=2026-10-05&end=2026-10-25", nil))
+		if rec.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d", tt.calendars, rec.Code, tt.status)
+		}
+
+		// The failure is described by its cause alone
+		body := rec.Body.String()
+		if !strings.Contains(body, "calendar unavailable (network)") {
+			t.Errorf("%s: response lacks the cause: %s", tt.calendars, body)
+		}
+		for _, secret := range []string{"s3cr3t", "127.0.0.1:1", "failed to download"} {
+			if strings.Contains(body, secret) {
+				t.Errorf("%s: response discloses %q: %s", tt.calendars, secret, body)
+			}
+		}
+
+		if tt.status == http.StatusOK {
+			var response struct {
+				Warnings []calendarWarning `json:"warnings"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if len(response.Warnings) != 1 || strings.Contains(response.Warnings[0].Message, feed.Name) {
+				t.Errorf("warnings = %+v, want one without the calendar name in its message", response.Warnings)
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mano/mucal/internal/config"
+)
+
+// calendarStatus holds the fields of a calendar status the tests assert on
+type calendarStatus struct {
+	Name                string `json:"name"`
+	Required            bool   `json:"required"`
+	LastAttempt         string `json:"lastAttempt"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Stale               bool   `json:"stale"`
+	Error               string `json:"error"`
+	Cause               string `json:"cause"`
+}
+
+func TestHealthReady(t *testing.T) {
+	server := newTestServer(t)
+	server.Fail("work", http.StatusInternalServerError)
+
+	tests := []struct {
+		name      string
+		required  string
+		wantCode  int
+		wantState string
+	}{
+		{name: "optional failing", required: "personal", wantCode: http.StatusOK, wantState: "degraded"},
+		{name: "required failing", required: "work", wantCode: http.StatusServiceUnavailable, wantState: "unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			personal, work := testCalendar(t, server, "personal"), testCalendar(t, server, "work")
+			personal.Required = tt.required == "personal"
+			work.Required = tt.required == "work"
+			h := newConfiguredHandler(t, personal, work)
+
+			// Wait for the first sync of both calendars
+			getEvents(t, h, "2026-10-05", "2026-10-11")
+
+			if code := get(t, h, "/api/health/ready", nil); code != tt.wantCode {
+				t.Errorf("ready status = %d, want %d", code, tt.wantCode)
+			}
+
+			var response struct {
+				Status    string           `json:"status"`
+				Calendars []calendarStatus `json:"calendars"`
+			}
+			if code := get(t, h, "/api/status", &response); code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", code, http.StatusOK)
+			}
+			if response.Status != tt.wantState {
+				t.Errorf("overall status = %q, want %q", response.Status, tt.wantState)
+			}
+			if len(response.Calendars) != 2 {
+				t.Fatalf("got %d calendars, want 2", len(response.Calendars))
+			}
+
+			ok, failing := response.Calendars[0], response.Calendars[1]
+			if ok.Name != "personal" || ok.Stale || ok.ConsecutiveFailures != 0 || ok.Error != "" || ok.LastAttempt == "" {
+				t.Errorf("working calendar status = %+v", ok)
+			}
+			if failing.Name != "work" || !failing.Stale || failing.ConsecutiveFailures != 1 || failing.Error == "" || failing.Cause != "other" {
+				t.Errorf("failing calendar status = %+v", failing)
+			}
+			if ok.Required != (tt.required == "personal") || failing.Required != (tt.required == "work") {
+				t.Errorf("required = %v and %v, want %q", ok.Required, failing.Required, tt.required)
+			}
+		})
+	}
+}
+
+func TestStatusAccess(t *testing.T) {
+	server := newTestServer(t)
+	server.Fail("work", http.StatusInternalServerError)
+
+	work := testCalendar(t, server, "work")
+	work.Required = true
+	h := newHandlerFor(t, &config.Config{
+		TimeZone:     "Europe/Rome",
+		AutoRefresh:  60,
+		SyncInterval: 3600,
+		Calendars:    []config.Calendar{testCalendar(t, server, "personal"), work},
+		Auth: config.Auth{
+			Mode:  config.AuthProxy,
+			Proxy: config.ProxyAuth{TrustedProxies: []string{"192.0.2.0/24"}},
+		},
+		Access: config.Access{
+			Users: []config.UserAccess{
+				{Username: "ada", Grant: config.Grant{Calendars: []string{"personal", "work"}}},
+				{Username: "bob", Grant: config.Grant{Calendars: []string{"personal"}}},
+			},
+		},
+	})
+
+	// Wait for the first sync of both calendars
+	getAs(t, h, "ada", "/api/events/month?year=2026&month=10", nil)
+
+	// A failing required calendar only shows to those who may see it
+	for user, want := range map[string]string{"ada": "unavailable", "bob": "ok"} {
+		var response struct {
+			Status    string           `json:"status"`
+			Calendars []calendarStatus `json:"calendars"`
+		}
+		if code := getAs(t, h, user, "/api/status", &response); code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", user, code, http.StatusOK)
+		}
+		if response.Status != want {
+			t.Errorf("%s: overall status = %q, want %q", user, response.Status, want)
+		}
+		if user == "bob" && (len(response.Calendars) != 1 || response.Calendars[0].Name != "personal") {
+			t.Errorf("bob: calendars = %+v, want personal only", response.Calendars)
+		}
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	// A private feed, with the secret in its URL
+	feed := config.Calendar{Name: "private", Type: config.TypeICS, URL: "http://127.0.0.1:1/feed.ics?key=s3cr3t", Color: "#FF6B6B"}
+	h := newConfiguredHandler(t, feed)
+
+	// Wait for the first sync
+	getEvents(t, h, "2026-10-05", "2026-10-11")
+
+	for _, target := range []string{"/api/status", "/api/sync"} {
+		rec := serve(h, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", target, rec.Code, http.StatusOK)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, `"error":"calendar unavailable (network)"`) {
+			t.Errorf("%s: response lacks the cause of the error: %s", target, body)
+		}
+		for _, secret := range []string{"s3cr3t", "127.0.0.1:1"} {
+			if strings.Contains(body, secret) {
+				t.Errorf("%s: response discloses %q: %s", target, secret, body)
+			}
+		}
+	}
+}
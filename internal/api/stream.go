@@ -0,0 +1,193 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mano/mucal/internal/cache"
+	"github.com/mano/mucal/internal/caldav"
+)
+
+const (
+	// streamBufferSize is the number of notifications buffered per client;
+	// clients falling further behind are disconnected and reconnect
+	streamBufferSize = 16
+
+	// streamKeepalive is the interval between keepalive comments
+	streamKeepalive = 15 * time.Second
+)
+
+// changeNotification is the payload of a "change" server-sent event
+type changeNotification struct {
+	Calendars []string           `json:"calendars"`
+	Ranges    []caldav.DateRange `json:"ranges"`
+}
+
+// broker fans calendar change notifications out to the connected streams,
+// each receiving those of the calendars its user may see
+type broker struct {
+	mu      sync.Mutex
+	clients map[chan []byte]access
+	closed  bool
+	done    chan struct{}
+}
+
+// newBroker creates a broker with no connected clients
+func newBroker() *broker {
+	return &broker{
+		clients: make(map[chan []byte]access),
+		done:    make(chan struct{}),
+	}
+}
+
+// subscribe registers a new client with the access of its user, returning
+// its notification channel. It returns nil once the broker is closed.
+func (b *broker) subscribe(a access) chan []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	ch := make(chan []byte, streamBufferSize)
+	b.clients[ch] = a
+	return ch
+}
+
+// unsubscribe removes a client, if still registered
+func (b *broker) unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.clients[ch]; ok {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+// publish sends a cache change to every client allowed to see the calendar,
+// without blocking
+func (b *broker) publish(change cache.Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, a := range b.clients {
+		if !a.allows(change.Calendar) {
+			continue
+		}
+		data, err := json.Marshal(changeNotification{
+			Calendars: []string{a.calendarName(change.Calendar)},
+			Ranges:    change.Ranges,
+		})
+		if err != nil {
+			slog.Error("failed to encode change notification", "error", err)
+			return
+		}
+
+		select {
+		case ch <- data:
+		default:
+			// Buffer full: drop the client, its EventSource reconnects
+			delete(b.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// close disconnects every client and refuses new ones
+func (b *broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.done)
+	for ch := range b.clients {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+// Stream handles the server-sent events endpoint, pushing a "change" event
+// whenever a sync finds changes in a calendar the user may see (or in the
+// selected view)
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	s := h.current()
+	a, err := s.accessFor(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	ch := h.broker.subscribe(a)
+	if ch == nil {
+		writeError(w, http.StatusServiceUnavailable, "server is shutting down")
+		return
+	}
+	defer h.broker.unsubscribe(ch)
+
+	// Streams outlive the server write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: change\ndata: %s\n\n", data)
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		case <-h.broker.done:
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// CloseStreams disconnects every event stream, so that a graceful server
+// shutdown does not wait for them
+func (h *Handler) CloseStreams() {
+	h.broker.close()
+}
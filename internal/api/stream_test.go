@@ -0,0 +1,183 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mano/mucal/internal/config"
+)
+
+// subscribe opens the event stream as the given user, returning the change
+// notifications it receives
+func subscribe(t *testing.T, url, user string) <-chan changeNotification {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url+"/api/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Remote-User", user)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/stream as %s: status %d", user, resp.StatusCode)
+	}
+
+	notifications := make(chan changeNotification, streamBufferSize)
+	scanner := bufio.NewScanner(resp.Body)
+	// Wait for the stream to be registered before returning
+	if !scanner.Scan() || scanner.Text() != ": connected" {
+		t.Fatalf("stream of %s did not connect", user)
+	}
+	go func() {
+		defer close(notifications)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var n changeNotification
+			if err := json.Unmarshal([]byte(data), &n); err != nil {
+				t.Errorf("invalid notification %q: %v", data, err)
+				return
+			}
+			notifications <- n
+		}
+	}()
+	return notifications
+}
+
+// awaitChange returns the first notification covering date, failing the
+// test if none arrives in time
+func awaitChange(t *testing.T, notifications <-chan changeNotification, date time.Time) changeNotification {
+	t.Helper()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case n, ok := <-notifications:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			for _, r := range n.Ranges {
+				if r.Overlaps(date, date.Add(time.Hour)) {
+					return n
+				}
+			}
+		case <-timeout:
+			t.Fatalf("no change notification for %s", date)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	server := newTestServer(t)
+	personal := testCalendar(t, server, "personal")
+	work := testCalendar(t, server, "work")
+	personal.RefreshInterval = 1
+	work.RefreshInterval = 1
+	h := newHandlerFor(t, &config.Config{
+		TimeZone:     "Europe/Rome",
+		AutoRefresh:  60,
+		SyncInterval: 3600,
+		Calendars:    []config.Calendar{personal, work},
+		Auth: config.Auth{
+			Mode:  config.AuthProxy,
+			Proxy: config.ProxyAuth{TrustedProxies: []string{"127.0.0.0/8", "192.0.2.0/24"}},
+		},
+		Access: config.Access{
+			Groups: []config.Group{
+				{Name: "team", Members: []string{"*"}, Grant: config.Grant{Calendars: []string{"work"}}},
+			},
+			Users: []config.UserAccess{
+				{Username: "ada", Grant: config.Grant{
+					Calendars: []string{"personal"},
+					Overrides: map[string]config.CalendarOverride{"work": {Name: "Office"}},
+				}},
+			},
+		},
+	})
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	// Let the first sync of both calendars complete, so that only the
+	// changes below are notified
+	if code := getAs(t, h, "ada", "/api/events/month?year=2026&month=10", nil); code != http.StatusOK {
+		t.Fatalf("GET /api/events/month: status %d", code)
+	}
+
+	ada := subscribe(t, ts.URL, "ada")
+	bob := subscribe(t, ts.URL, "bob")
+
+	event := func(uid string, start time.Time) string {
+		return "BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"PRODID:-//mucal//test//EN\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:" + uid + "\r\n" +
+			"DTSTAMP:20260901T000000Z\r\n" +
+			"DTSTART:" + start.Format("20060102T150405Z") + "\r\n" +
+			"DURATION:PT1H\r\n" +
+			"SUMMARY:" + uid + "\r\n" +
+			"END:VEVENT\r\n" +
+			"END:VCALENDAR\r\n"
+	}
+
+	// A personal change reaches ada only
+	dentist := time.Date(2031, 5, 6, 9, 0, 0, 0, time.UTC)
+	if err := server.Put("personal", "dentist.ics", event("dentist", dentist)); err != nil {
+		t.Fatal(err)
+	}
+	if n := awaitChange(t, ada, dentist); strings.Join(n.Calendars, ",") != "personal" {
+		t.Errorf("ada notified of %v, want [personal]", n.Calendars)
+	}
+
+	// A work change reaches both, under the name each user sees. Bob's
+	// stream received the personal change before it, if at all.
+	review := time.Date(2031, 5, 7, 14, 0, 0, 0, time.UTC)
+	if err := server.Put("work", "review.ics", event("review", review)); err != nil {
+		t.Fatal(err)
+	}
+	if n := awaitChange(t, ada, review); strings.Join(n.Calendars, ",") != "Office" {
+		t.Errorf("ada notified of %v, want [Office]", n.Calendars)
+	}
+	timeout := time.After(10 * time.Second)
+	for done := false; !done; {
+		select {
+		case n, ok := <-bob:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			if strings.Join(n.Calendars, ",") != "work" {
+				t.Fatalf("bob notified of %v, want [work]", n.Calendars)
+			}
+			for _, r := range n.Ranges {
+				done = done || r.Overlaps(review, review.Add(time.Hour))
+			}
+		case <-timeout:
+			t.Fatal("bob not notified of the work change")
+		}
+	}
+}
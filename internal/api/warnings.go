@@ -0,0 +1,83 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mano/mucal/internal/caldav"
+	"github.com/mano/mucal/internal/logging"
+)
+
+// calendarWarning reports a calendar left out of a response because it
+// could not be fetched, so that clients can tell users what is missing
+type calendarWarning struct {
+	Calendar string `json:"calendar"`
+	Cause    string `json:"cause"`
+	Message  string `json:"message"`
+}
+
+// failures collects the calendars that could not be fetched for a request.
+// It is safe for concurrent use.
+type failures struct {
+	mu       sync.Mutex
+	warnings []calendarWarning
+}
+
+// add records the failure of a calendar, under the name the user knows it
+// by. The error itself, which may hold the calendar URL and configured name,
+// is only logged; the user is told its cause.
+func (f *failures) add(r *http.Request, a access, source caldav.Source, err error) {
+	cause := caldav.ErrorCause(err)
+	logging.FromContext(r.Context()).Warn("calendar unavailable",
+		"calendar", source.GetCalendarName(), "cause", cause, "error", err)
+
+	name := a.calendarName(source.GetCalendarName())
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.warnings = append(f.warnings, calendarWarning{Calendar: name, Cause: cause, Message: caldav.ErrorSummary(err)})
+}
+
+// all reports whether every one of n calendars failed
+func (f *failures) all(n int) bool {
+	return len(f.warnings) > 0 && len(f.warnings) == n
+}
+
+// writeError writes the error response of a request for which every
+// calendar failed
+func (f *failures) writeError(w http.ResponseWriter, what string) {
+	messages := make([]string, len(f.warnings))
+	for i, warning := range f.warnings {
+		messages[i] = fmt.Sprintf("%s: %s", warning.Calendar, warning.Message)
+	}
+	sort.Strings(messages)
+	writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to fetch %s: %s", what, strings.Join(messages, "; ")))
+}
+
+// report adds the failed calendars to a response as its "warnings", sorted
+// by calendar
+func (f *failures) report(response map[string]interface{}) {
+	if len(f.warnings) == 0 {
+		return
+	}
+	sort.Slice(f.warnings, func(i, j int) bool {
+		return f.warnings[i].Calendar < f.warnings[j].Calendar
+	})
+	response["warnings"] = f.warnings
+}
@@ -0,0 +1,303 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mano/mucal/internal/caldav"
+	"github.com/mano/mucal/internal/logging"
+	"github.com/mano/mucal/internal/metrics"
+)
+
+var (
+	fetchDuration = metrics.NewHistogramVec("mucal_calendar_fetch_duration_seconds",
+		"Duration of calendar syncs, successful or not.", metrics.DefaultBuckets, "calendar")
+	fetchErrors = metrics.NewCounterVec("mucal_calendar_fetch_errors_total",
+		"Failed calendar syncs, by cause: auth, timeout, parse, network or other.", "calendar", "cause")
+	cacheRequests = metrics.NewCounterVec("mucal_cache_requests_total",
+		"Requests for the objects of a calendar, by result: hit when served from the cache, miss when waiting for the first sync or failing for lack of a successful one.", "calendar", "result")
+)
+
+// Cache keeps the calendar objects of every calendar in memory, refreshing
+// them in the background, so that API requests never wait for the CalDAV
+// servers once the first sync has completed
+type Cache struct {
+	interval time.Duration
+	entries  map[caldav.Source]*entry
+	order    []caldav.Source
+	onChange func(Change)
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// entry holds the cached state of a single calendar
+type entry struct {
+	mu          sync.RWMutex
+	objects     []caldav.CalendarObject
+	lastSync    time.Time
+	lastAttempt time.Time
+	lastErr     error
+	failures    int
+	latency     time.Duration
+
+	// ready is closed once the first sync attempt has completed
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// CalendarStatus reports the sync state of a calendar: when it last synced
+// successfully and last tried to, how long that took, and the error and
+// cause of the last attempt, along with how many attempts failed in a row
+type CalendarStatus struct {
+	Name                string     `json:"name"`
+	Required            bool       `json:"required"`
+	LastSync            *time.Time `json:"lastSync"`
+	LastAttempt         *time.Time `json:"lastAttempt"`
+	LatencyMs           int64      `json:"latencyMs"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Stale               bool       `json:"stale"`
+	Error               string     `json:"error,omitempty"`
+	Cause               string     `json:"cause,omitempty"`
+}
+
+// Change reports that a sync found new, modified or deleted objects in a
+// calendar, and which dates they cover
+type Change struct {
+	Calendar string
+	Ranges   []caldav.DateRange
+}
+
+// New creates a cache for the given sources, refreshed every interval
+// unless a calendar has its own refresh interval. onChange, if not nil, is
+// called after each sync that changed a calendar.
+func New(sources []caldav.Source, interval time.Duration, onChange func(Change)) *Cache {
+	c := &Cache{
+		interval: interval,
+		entries:  make(map[caldav.Source]*entry, len(sources)),
+		order:    sources,
+		onChange: onChange,
+	}
+	for _, source := range sources {
+		c.entries[source] = &entry{ready: make(chan struct{})}
+	}
+	return c
+}
+
+// Adopt takes over the state of the calendars of another cache that match
+// its own, so that their objects are served until they are synced again.
+// Sources implementing caldav.Adopter also take over the sync state of the
+// previous ones, so that they only fetch what changed since. It must be
+// called before Start.
+func (c *Cache) Adopt(old *Cache, match func(old, source caldav.Source) bool) {
+	for _, source := range c.order {
+		for _, oldSource := range old.order {
+			if !match(oldSource, source) {
+				continue
+			}
+
+			o, e := old.entries[oldSource], c.entries[source]
+			o.mu.RLock()
+			e.objects, e.lastSync, e.lastAttempt, e.lastErr = o.objects, o.lastSync, o.lastAttempt, o.lastErr
+			e.failures, e.latency = o.failures, o.latency
+			o.mu.RUnlock()
+
+			if !e.lastAttempt.IsZero() {
+				e.readyOnce.Do(func() { close(e.ready) })
+			}
+			if adopter, ok := source.(caldav.Adopter); ok {
+				adopter.Adopt(oldSource)
+			}
+			break
+		}
+	}
+}
+
+// Start launches a background sync loop for every calendar. The first sync
+// runs immediately.
+func (c *Cache) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	for _, source := range c.order {
+		c.wg.Add(1)
+		go func(source caldav.Source) {
+			defer c.wg.Done()
+			c.run(ctx, source)
+		}(source)
+	}
+}
+
+// Stop stops the sync loops and waits for them to exit
+func (c *Cache) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+}
+
+// run syncs a calendar until the context is cancelled
+func (c *Cache) run(ctx context.Context, source caldav.Source) {
+	ticker := time.NewTicker(c.intervalOf(source))
+	defer ticker.Stop()
+
+	for {
+		c.sync(ctx, source)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// intervalOf returns how often a calendar is synced
+func (c *Cache) intervalOf(source caldav.Source) time.Duration {
+	if interval := source.RefreshInterval(); interval > 0 {
+		return interval
+	}
+	return c.interval
+}
+
+// sync refreshes the cached objects of a calendar. On failure the previous
+// objects are kept and served as stale.
+func (c *Cache) sync(ctx context.Context, source caldav.Source) {
+	// Each sync gets its own ID, sent along with its requests
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+
+	e := c.entries[source]
+	started := time.Now()
+	objects, changed, err := source.Sync(ctx)
+	now := time.Now()
+
+	e.mu.Lock()
+	e.lastAttempt = now
+	e.lastErr = err
+	e.latency = now.Sub(started)
+	if err == nil {
+		e.objects = objects
+		e.lastSync = now
+		e.failures = 0
+	} else {
+		e.failures++
+	}
+	e.mu.Unlock()
+
+	// Syncs interrupted by a shutdown are not measured
+	if ctx.Err() == nil {
+		fetchDuration.Observe(now.Sub(started).Seconds(), source.GetCalendarName())
+		if err != nil {
+			cause := caldav.ErrorCause(err)
+			logging.FromContext(ctx).Error("failed to sync calendar",
+				"calendar", source.GetCalendarName(), "cause", cause, "error", err)
+			fetchErrors.Inc(source.GetCalendarName(), cause)
+		}
+	}
+
+	e.readyOnce.Do(func() { close(e.ready) })
+
+	if len(changed) > 0 && c.onChange != nil {
+		c.onChange(Change{Calendar: source.GetCalendarName(), Ranges: changed})
+	}
+}
+
+// Events returns the events of a calendar within the given time range,
+// expanded from the cached objects. It waits for the first sync attempt of
+// the calendar, and fails if no sync has ever succeeded.
+func (c *Cache) Events(ctx context.Context, source caldav.Source, start, end time.Time) ([]*caldav.Event, error) {
+	objects, err := c.Objects(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	return source.ExpandEvents(ctx, objects, start, end), nil
+}
+
+// Objects returns the cached calendar objects of a calendar. Like Events,
+// it waits for the first sync attempt of the calendar.
+func (c *Cache) Objects(ctx context.Context, source caldav.Source) ([]caldav.CalendarObject, error) {
+	e, ok := c.entries[source]
+	if !ok {
+		return nil, fmt.Errorf("calendar %s is not cached", source.GetCalendarName())
+	}
+
+	result := "hit"
+	select {
+	case <-e.ready:
+	default:
+		result = "miss"
+		select {
+		case <-e.ready:
+		case <-ctx.Done():
+			cacheRequests.Inc(source.GetCalendarName(), result)
+			return nil, ctx.Err()
+		}
+	}
+
+	e.mu.RLock()
+	objects, lastSync, lastErr := e.objects, e.lastSync, e.lastErr
+	e.mu.RUnlock()
+
+	if lastSync.IsZero() {
+		cacheRequests.Inc(source.GetCalendarName(), "miss")
+		return nil, fmt.Errorf("no successful sync yet: %w", lastErr)
+	}
+
+	cacheRequests.Inc(source.GetCalendarName(), result)
+	return objects, nil
+}
+
+// Status returns the sync state of every calendar. A calendar is stale when
+// its last sync attempt failed or no sync succeeded for two intervals.
+func (c *Cache) Status() []CalendarStatus {
+	now := time.Now()
+	statuses := make([]CalendarStatus, 0, len(c.order))
+
+	for _, source := range c.order {
+		e := c.entries[source]
+
+		e.mu.RLock()
+		status := CalendarStatus{
+			Name:                source.GetCalendarName(),
+			Required:            source.GetCalendar().Required,
+			LatencyMs:           e.latency.Milliseconds(),
+			ConsecutiveFailures: e.failures,
+		}
+		if !e.lastSync.IsZero() {
+			lastSync := e.lastSync
+			status.LastSync = &lastSync
+		}
+		if !e.lastAttempt.IsZero() {
+			lastAttempt := e.lastAttempt
+			status.LastAttempt = &lastAttempt
+		}
+		if e.lastErr != nil {
+			// The full error, logged on sync, may hold the calendar URL
+			status.Error = caldav.ErrorSummary(e.lastErr)
+			status.Cause = caldav.ErrorCause(e.lastErr)
+		}
+		status.Stale = e.lastSync.IsZero() || e.lastErr != nil || now.Sub(e.lastSync) > 2*c.intervalOf(source)
+		e.mu.RUnlock()
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
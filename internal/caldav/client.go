@@ -17,23 +17,24 @@ package caldav
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/emersion/go-ical"
 	"github.com/emersion/go-webdav/caldav"
 	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/logging"
 )
 
-// Client wraps the CalDAV client with calendar configuration
+// Client is the source of a CalDAV calendar collection
 type Client struct {
+	*SourceBase
 	httpClient   *http.Client
 	caldavClient *caldav.Client
-	calendar     *config.Calendar
-	timezone     *time.Location
 }
 
 // NewClient creates a new CalDAV client for the given calendar
@@ -44,13 +45,7 @@ func NewClient(cal *config.Calendar, tz *time.Location) (*Client, error) {
 	}
 
 	// Create HTTP client with Basic Auth
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &basicAuthTransport{
-			Username: cal.UserID,
-			Password: password,
-		},
-	}
+	httpClient := newHTTPClient(cal.UserID, password)
 
 	// Create CalDAV client
 	caldavClient, err := caldav.NewClient(httpClient, cal.URL)
@@ -59,95 +54,195 @@ func NewClient(cal *config.Calendar, tz *time.Location) (*Client, error) {
 	}
 
 	return &Client{
+		SourceBase:   NewSourceBase(cal, tz),
 		httpClient:   httpClient,
 		caldavClient: caldavClient,
-		calendar:     cal,
-		timezone:     tz,
 	}, nil
 }
 
+// CalendarObject is a calendar resource, as stored on a CalDAV server or
+// read from another source
+type CalendarObject = caldav.CalendarObject
+
 // FetchEvents fetches calendar events within the given time range
 func (c *Client) FetchEvents(start, end time.Time) ([]*Event, error) {
-	// Query for calendar objects within the date range
+	objects, err := c.query(context.Background(), timeRangeFilter("VEVENT", start, end))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ExpandEvents(context.Background(), objects, start, end), nil
+}
+
+// FetchObjects fetches every calendar object, regardless of its dates, so
+// that they can be cached and expanded later
+func (c *Client) FetchObjects(ctx context.Context) ([]CalendarObject, error) {
+	return c.query(ctx, caldav.CompFilter{Name: "VCALENDAR"})
+}
+
+// query queries the calendar objects matching the given filter
+func (c *Client) query(ctx context.Context, filter caldav.CompFilter) ([]CalendarObject, error) {
 	query := &caldav.CalendarQuery{
-		CompRequest: caldav.CalendarCompRequest{
-			Name:  "VCALENDAR",
-			Props: []string{"VERSION"},
-			Comps: []caldav.CalendarCompRequest{
-				{
-					Name: "VEVENT",
-					Props: []string{
-						"UID",
-						"SUMMARY",
-						"DESCRIPTION",
-						"LOCATION",
-						"DTSTART",
-						"DTEND",
-						"DURATION",
-						"RRULE",
-						"EXDATE",
-						"RECURRENCE-ID",
-					},
-				},
-			},
-		},
-		CompFilter: caldav.CompFilter{
-			Name: "VCALENDAR",
-			Comps: []caldav.CompFilter{
-				{
-					Name:  "VEVENT",
-					Start: start,
-					End:   end,
-				},
-			},
-		},
+		CompRequest: calendarCompRequest(),
+		CompFilter:  filter,
 	}
 
 	// Fetch calendar objects
-	ctx := context.Background()
 	objects, err := c.caldavClient.QueryCalendar(ctx, "", query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query calendar %s: %w", c.calendar.Name, err)
 	}
 
+	return objects, nil
+}
+
+// timeRangeFilter matches the objects with a component of the given type
+// within the time range
+func timeRangeFilter(comp string, start, end time.Time) caldav.CompFilter {
+	return caldav.CompFilter{
+		Name: "VCALENDAR",
+		Comps: []caldav.CompFilter{
+			{
+				Name:  comp,
+				Start: start,
+				End:   end,
+			},
+		},
+	}
+}
+
+// calendarCompRequest lists the event and task properties requested from
+// the server, along with the time zone definitions they refer to. Events
+// are requested in full, with their alarms, so that feeds reproduce them as
+// stored; tasks only with the properties that are shown.
+func calendarCompRequest() caldav.CalendarCompRequest {
+	return caldav.CalendarCompRequest{
+		Name:  "VCALENDAR",
+		Props: []string{"VERSION"},
+		Comps: []caldav.CalendarCompRequest{
+			{
+				Name:     "VTIMEZONE",
+				AllProps: true,
+				AllComps: true,
+			},
+			{
+				Name:     "VEVENT",
+				AllProps: true,
+				AllComps: true,
+			},
+			{
+				Name: "VTODO",
+				Props: []string{
+					"UID",
+					"SUMMARY",
+					"DESCRIPTION",
+					"DTSTART",
+					"DUE",
+					"DURATION",
+					"COMPLETED",
+					"CREATED",
+					"STATUS",
+					"PERCENT-COMPLETE",
+					"PRIORITY",
+					"CLASS",
+				},
+			},
+		},
+	}
+}
+
+// ExpandEvents parses calendar objects into the events overlapping the
+// given time range, expanding recurring events
+func (c *SourceBase) ExpandEvents(ctx context.Context, objects []CalendarObject, start, end time.Time) []*Event {
 	// Parse events from calendar objects
 	var events []*Event
 	for _, obj := range objects {
-		parsedEvents, err := c.parseCalendarObject(&obj, start, end)
+		log := c.objectLogger(ctx, &obj)
+		parsedEvents, err := c.parseCalendarObject(log, &obj, start, end)
 		if err != nil {
 			// Log error but continue processing other events
-			fmt.Fprintf(os.Stderr, "Error parsing calendar object in %s: %v\n", c.calendar.Name, err)
+			log.Warn("failed to parse calendar object", "error", err)
+			parseErrors.Inc(c.calendar.Name)
 			continue
 		}
-		events = append(events, parsedEvents...)
+		eventsParsed.Add(float64(len(parsedEvents)), c.calendar.Name)
+
+		// Objects may come from a cache covering any dates
+		for _, event := range parsedEvents {
+			if event.overlaps(start, end) {
+				events = append(events, event)
+			}
+		}
 	}
 
 	// Sort events
 	sort.Sort(Events(events))
 
-	return events, nil
+	return events
 }
 
 // parseCalendarObject parses a CalDAV calendar object into events
-func (c *Client) parseCalendarObject(obj *caldav.CalendarObject, queryStart, queryEnd time.Time) ([]*Event, error) {
+func (c *SourceBase) parseCalendarObject(log *slog.Logger, obj *caldav.CalendarObject, queryStart, queryEnd time.Time) ([]*Event, error) {
 	// obj.Data is already an *ical.Calendar
 	cal := obj.Data
 	if cal == nil {
 		return nil, fmt.Errorf("calendar object has no data")
 	}
+	c.loadTimezones(log, cal)
 
-	var events []*Event
+	// Group VEVENTs by UID so that RECURRENCE-ID overrides can be applied
+	// to the occurrences generated by their master event
+	var groups []*eventGroup
+	byUID := make(map[string]*eventGroup)
 
-	// Process each VEVENT
 	for _, comp := range cal.Children {
 		if comp.Name != "VEVENT" {
 			continue
 		}
 
-		event, err := c.parseEvent(comp, queryStart, queryEnd)
+		uid := comp.Props.Get("UID")
+		if uid == nil {
+			log.Warn("failed to parse event", "error", "event missing UID")
+			parseErrors.Inc(c.calendar.Name)
+			continue
+		}
+
+		group, ok := byUID[uid.Value]
+		if !ok {
+			group = &eventGroup{}
+			byUID[uid.Value] = group
+			groups = append(groups, group)
+		}
+
+		if comp.Props.Get("RECURRENCE-ID") != nil {
+			group.overrides = append(group.overrides, comp)
+		} else {
+			group.master = comp
+		}
+	}
+
+	var events []*Event
+
+	for _, group := range groups {
+		// Overrides without a master are shown as standalone events
+		if group.master == nil {
+			for _, comp := range group.overrides {
+				override, err := c.parseOverride(log, comp, c.timezone)
+				if err != nil {
+					log.Warn("failed to parse event", "uid", textProp(comp, "UID"), "error", err)
+					parseErrors.Inc(c.calendar.Name)
+					continue
+				}
+				events = append(events, c.overrideEvent(comp.Props.Get("UID").Value, override))
+			}
+			continue
+		}
+
+		event, err := c.parseEvent(log, group.master, group.overrides, queryStart, queryEnd)
 		if err != nil {
 			// Log error but continue
-			fmt.Fprintf(os.Stderr, "Error parsing event in %s: %v\n", c.calendar.Name, err)
+			log.Warn("failed to parse event", "uid", textProp(group.master, "UID"), "error", err)
+			parseErrors.Inc(c.calendar.Name)
 			continue
 		}
 
@@ -159,38 +254,144 @@ func (c *Client) parseCalendarObject(obj *caldav.CalendarObject, queryStart, que
 	return events, nil
 }
 
-// parseEvent parses a single VEVENT component
-func (c *Client) parseEvent(comp *ical.Component, queryStart, queryEnd time.Time) ([]*Event, error) {
+// eventGroup holds the VEVENTs sharing a UID within a calendar object:
+// the master event and the instances overriding some of its occurrences
+type eventGroup struct {
+	master    *ical.Component
+	overrides []*ical.Component
+}
+
+// parseEvent parses a single VEVENT component, applying the given
+// RECURRENCE-ID overrides if the event is recurring
+func (c *SourceBase) parseEvent(log *slog.Logger, comp *ical.Component, overrides []*ical.Component, queryStart, queryEnd time.Time) ([]*Event, error) {
 	// Extract basic properties
 	uid := comp.Props.Get("UID")
 	if uid == nil {
 		return nil, fmt.Errorf("event missing UID")
 	}
+	c.warnUnknownTimezones(log, comp)
+
+	details := c.parseEventDetails(comp)
 
-	summary := ""
-	if prop := comp.Props.Get("SUMMARY"); prop != nil {
-		summary = unescapeICalText(prop.Value)
+	startTime, endTime, allDay, err := c.parseEventTimes(comp)
+	if err != nil {
+		return nil, err
 	}
 
-	description := ""
-	if prop := comp.Props.Get("DESCRIPTION"); prop != nil {
-		description = unescapeICalText(prop.Value)
+	// Check if event has recurrence rule or additional dates
+	if comp.Props.Get("RRULE") != nil || comp.Props.Get("RDATE") != nil {
+		// Recurring event - expand it
+		return c.expandRecurringEvent(log, comp, overrides, uid.Value, details,
+			startTime, endTime, allDay, queryStart, queryEnd)
 	}
 
-	location := ""
-	if prop := comp.Props.Get("LOCATION"); prop != nil {
-		location = unescapeICalText(prop.Value)
+	// Single event
+	return []*Event{c.newEvent(uid.Value, details, startTime, endTime, allDay, false)}, nil
+}
+
+// eventDetails holds the descriptive properties of a VEVENT, which an
+// override may change for some occurrences
+type eventDetails struct {
+	summary      string
+	description  string
+	location     string
+	status       string
+	transparency string
+	class        string
+	categories   []string
+	url          string
+	priority     int
+	organizer    *Attendee
+	attendees    []Attendee
+}
+
+// parseEventDetails extracts the descriptive properties of a VEVENT
+func (c *SourceBase) parseEventDetails(comp *ical.Component) eventDetails {
+	details := eventDetails{
+		summary:      textProp(comp, "SUMMARY"),
+		description:  textProp(comp, "DESCRIPTION"),
+		location:     textProp(comp, "LOCATION"),
+		status:       strings.ToUpper(textProp(comp, "STATUS")),
+		transparency: strings.ToUpper(textProp(comp, "TRANSP")),
+		class:        strings.ToUpper(textProp(comp, "CLASS")),
+		url:          textProp(comp, "URL"),
+	}
+
+	for _, prop := range comp.Props.Values("CATEGORIES") {
+		for _, category := range splitICalText(prop.Value) {
+			if category = strings.TrimSpace(category); category != "" {
+				details.categories = append(details.categories, category)
+			}
+		}
+	}
+
+	if prop := comp.Props.Get("PRIORITY"); prop != nil {
+		details.priority, _ = strconv.Atoi(strings.TrimSpace(prop.Value))
+	}
+
+	if prop := comp.Props.Get("ORGANIZER"); prop != nil {
+		organizer := parseAttendee(prop)
+		details.organizer = &organizer
+	}
+	for _, prop := range comp.Props.Values("ATTENDEE") {
+		details.attendees = append(details.attendees, parseAttendee(&prop))
+	}
+
+	return details
+}
+
+// parseAttendee parses an ORGANIZER or ATTENDEE property, whose value is
+// usually a mailto: URI
+func parseAttendee(prop *ical.Prop) Attendee {
+	email := strings.TrimSpace(prop.Value)
+	if len(email) >= len("mailto:") && strings.EqualFold(email[:len("mailto:")], "mailto:") {
+		email = email[len("mailto:"):]
+	}
+
+	return Attendee{
+		Name:   prop.Params.Get("CN"),
+		Email:  email,
+		Role:   strings.ToUpper(prop.Params.Get("ROLE")),
+		Status: strings.ToUpper(prop.Params.Get("PARTSTAT")),
 	}
+}
 
+// newEvent builds an event of the calendar
+func (c *SourceBase) newEvent(uid string, details eventDetails, start, end time.Time, allDay, recurring bool) *Event {
+	return &Event{
+		UID:           uid,
+		Summary:       details.summary,
+		Description:   details.description,
+		Location:      details.location,
+		Start:         start,
+		End:           end,
+		AllDay:        allDay,
+		Status:        details.status,
+		Transparency:  details.transparency,
+		Class:         details.class,
+		Categories:    details.categories,
+		URL:           details.url,
+		Priority:      details.priority,
+		Organizer:     details.organizer,
+		Attendees:     details.attendees,
+		CalendarName:  c.calendar.Name,
+		CalendarColor: c.calendar.Color,
+		IsRecurring:   recurring,
+	}
+}
+
+// parseEventTimes extracts the start and end of a VEVENT, deriving the end
+// from DURATION or the event type when DTEND is missing
+func (c *SourceBase) parseEventTimes(comp *ical.Component) (time.Time, time.Time, bool, error) {
 	// Parse start time
 	dtstart := comp.Props.Get("DTSTART")
 	if dtstart == nil {
-		return nil, fmt.Errorf("event missing DTSTART")
+		return time.Time{}, time.Time{}, false, fmt.Errorf("event missing DTSTART")
 	}
 
 	startTime, allDay, err := c.parseDateTime(dtstart)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DTSTART: %w", err)
+		return time.Time{}, time.Time{}, false, fmt.Errorf("failed to parse DTSTART: %w", err)
 	}
 
 	// Parse end time
@@ -201,54 +402,47 @@ func (c *Client) parseEvent(comp *ical.Component, queryStart, queryEnd time.Time
 	if dtend != nil {
 		endTime, _, err = c.parseDateTime(dtend)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse DTEND: %w", err)
+			return time.Time{}, time.Time{}, false, fmt.Errorf("failed to parse DTEND: %w", err)
 		}
 	} else if duration != nil {
 		// Parse duration
 		dur, err := parseDuration(duration.Value)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse DURATION: %w", err)
+			return time.Time{}, time.Time{}, false, fmt.Errorf("failed to parse DURATION: %w", err)
+		}
+		// Whole days of all-day events are calendar days, whatever
+		// their length in hours
+		if allDay && dur%(24*time.Hour) == 0 {
+			endTime = startTime.AddDate(0, 0, int(dur/(24*time.Hour)))
+		} else {
+			endTime = startTime.Add(dur)
 		}
-		endTime = startTime.Add(dur)
 	} else {
 		// Default: all-day events are 1 day, timed events are 0 duration
 		if allDay {
-			endTime = startTime.Add(24 * time.Hour)
+			endTime = startTime.AddDate(0, 0, 1)
 		} else {
 			endTime = startTime
 		}
 	}
 
-	// Check if event has recurrence rule
-	rrule := comp.Props.Get("RRULE")
-	if rrule != nil {
-		// Recurring event - expand it
-		return c.expandRecurringEvent(comp, uid.Value, summary, description, location,
-			startTime, endTime, allDay, queryStart, queryEnd)
-	}
+	return startTime, endTime, allDay, nil
+}
 
-	// Single event
-	event := &Event{
-		UID:          uid.Value,
-		Summary:      summary,
-		Description:  description,
-		Location:     location,
-		Start:        startTime,
-		End:          endTime,
-		AllDay:       allDay,
-		CalendarName: c.calendar.Name,
-		CalendarColor: c.calendar.Color,
-		IsRecurring:  false,
+// textProp returns the unescaped value of a TEXT property, or "" if absent
+func textProp(comp *ical.Component, name string) string {
+	if prop := comp.Props.Get(name); prop != nil {
+		return unescapeICalText(prop.Value)
 	}
-
-	return []*Event{event}, nil
+	return ""
 }
 
 // parseDateTime parses an iCalendar date/time property
-func (c *Client) parseDateTime(prop *ical.Prop) (time.Time, bool, error) {
+func (c *SourceBase) parseDateTime(prop *ical.Prop) (time.Time, bool, error) {
 	// Check if it's a DATE (all-day) or DATE-TIME
+	// Some producers omit VALUE=DATE, so also recognize bare YYYYMMDD values
 	valueType := prop.Params.Get("VALUE")
-	isDate := valueType == "DATE"
+	isDate := valueType == "DATE" || len(prop.Value) == len("20060102")
 
 	var t time.Time
 	var err error
@@ -267,11 +461,7 @@ func (c *Client) parseDateTime(prop *ical.Prop) (time.Time, bool, error) {
 	tzid := prop.Params.Get("TZID")
 	if tzid != "" {
 		// Parse with specific timezone
-		loc, err := time.LoadLocation(tzid)
-		if err != nil {
-			// Fallback to configured timezone
-			loc = c.timezone
-		}
+		loc := c.tzidLocation(tzid)
 
 		// Parse timestamp (YYYYMMDDTHHMMSS or YYYYMMDDTHHMMSSZ)
 		t, err = time.ParseInLocation("20060102T150405", prop.Value, loc)
@@ -301,9 +491,29 @@ func (c *Client) parseDateTime(prop *ical.Prop) (time.Time, bool, error) {
 	return t, false, nil
 }
 
-// GetCalendarName returns the name of the calendar
-func (c *Client) GetCalendarName() string {
-	return c.calendar.Name
+// tzidLocation returns the location of a TZID parameter, falling back to
+// the configured timezone for unknown time zones
+func (c *SourceBase) tzidLocation(tzid string) *time.Location {
+	loc, ok := c.resolveTZID(tzid)
+	if !ok {
+		return c.timezone
+	}
+	return loc
+}
+
+// dateTimeLocation returns the location the value of a date/time property
+// is expressed in: UTC, its TZID, or the configured timezone for floating
+// times and dates
+func (c *SourceBase) dateTimeLocation(prop *ical.Prop, allDay bool) *time.Location {
+	switch {
+	case prop == nil || allDay:
+		return c.timezone
+	case strings.HasSuffix(prop.Value, "Z"):
+		return time.UTC
+	case prop.Params.Get("TZID") != "":
+		return c.tzidLocation(prop.Params.Get("TZID"))
+	}
+	return c.timezone
 }
 
 // unescapeICalText unescapes iCalendar TEXT values according to RFC 5545
@@ -335,6 +545,42 @@ func unescapeICalText(s string) string {
 	return result.String()
 }
 
+// splitICalText splits a list of TEXT values, like CATEGORIES, on the commas
+// not escaped, unescaping each value
+func splitICalText(s string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // Skip the escaped character
+		case ',':
+			values = append(values, unescapeICalText(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, unescapeICalText(s[start:]))
+}
+
+// newHTTPClient creates an HTTP client authenticating with Basic Auth
+func newHTTPClient(username, password string) *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &basicAuthTransport{
+			Username: username,
+			Password: password,
+		},
+	}
+}
+
+// newAnonymousHTTPClient creates an HTTP client without authentication
+func newAnonymousHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: requestIDTransport{},
+	}
+}
+
 // basicAuthTransport is an http.RoundTripper that adds Basic Authentication
 type basicAuthTransport struct {
 	Username string
@@ -343,5 +589,18 @@ type basicAuthTransport struct {
 
 func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.SetBasicAuth(t.Username, t.Password)
+	return requestIDTransport{}.RoundTrip(req)
+}
+
+// requestIDTransport is an http.RoundTripper that forwards the request ID of
+// the request context in the X-Request-ID header, so that server logs can be
+// matched with ours
+type requestIDTransport struct{}
+
+func (requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := logging.RequestID(req.Context()); id != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
 	return http.DefaultTransport.RoundTrip(req)
 }
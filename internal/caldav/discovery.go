@@ -0,0 +1,216 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/emersion/go-webdav/caldav"
+	"github.com/mano/mucal/internal/config"
+)
+
+// defaultColor is used for discovered calendars when neither the server,
+// the account nor an override provide a color
+const defaultColor = "#6C757D"
+
+// DiscoverCalendars finds every calendar collection of a CalDAV account by
+// following the well-known URL, the current user principal and its
+// calendar-home-set. The returned calendars share the account credentials,
+// and name it as their account.
+func DiscoverCalendars(ctx context.Context, acc *config.Account) ([]config.Calendar, error) {
+	password, err := acc.GetPassword()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password for account %s: %w", acc.Name, err)
+	}
+
+	httpClient := newHTTPClient(acc.UserID, password)
+
+	endpoint, err := resolveWellKnown(ctx, httpClient, acc.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	caldavClient, err := caldav.NewClient(httpClient, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CalDAV client for %s: %w", acc.Name, err)
+	}
+
+	principal, err := caldavClient.FindCurrentUserPrincipal(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find current user principal: %w", err)
+	}
+
+	homeSet, err := caldavClient.FindCalendarHomeSet(ctx, principal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find calendar home set: %w", err)
+	}
+
+	calendars, err := caldavClient.FindCalendars(ctx, homeSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %w", endpoint, err)
+	}
+
+	// Colors are optional, so a failure only loses them
+	colors, err := findCalendarColors(ctx, httpClient, base.ResolveReference(&url.URL{Path: homeSet}).String())
+	if err != nil {
+		colors = nil
+	}
+
+	var result []config.Calendar
+	for _, cal := range calendars {
+		if !supportsObjects(cal) {
+			continue
+		}
+
+		name := cal.Name
+		if name == "" {
+			name = path.Base(strings.TrimSuffix(cal.Path, "/"))
+		}
+		if !acc.Matches(name) {
+			continue
+		}
+
+		color := colors[strings.TrimSuffix(cal.Path, "/")]
+		if color == "" {
+			color = acc.Color
+		}
+		if color == "" {
+			color = defaultColor
+		}
+
+		if override, ok := acc.Overrides[name]; ok {
+			if override.Name != "" {
+				name = override.Name
+			}
+			if override.Color != "" {
+				color = override.Color
+			}
+		}
+
+		result = append(result, config.Calendar{
+			Name:         name,
+			URL:          base.ResolveReference(&url.URL{Path: cal.Path}).String(),
+			UserID:       acc.UserID,
+			PasswordFile: acc.PasswordFile,
+			Color:        color,
+			Account:      acc.Name,
+		})
+	}
+
+	return result, nil
+}
+
+// resolveWellKnown returns the CalDAV context URL for a server. When the
+// configured URL has no path, /.well-known/caldav (RFC 6764) is tried first;
+// its redirect is followed by hand since PROPFIND cannot be redirected.
+func resolveWellKnown(ctx context.Context, httpClient *http.Client, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return rawURL, nil
+	}
+
+	wellKnown := u.ResolveReference(&url.URL{Path: "/.well-known/caldav"})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	noRedirect := *httpClient
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := noRedirect.Do(req)
+	if err != nil {
+		return rawURL, nil
+	}
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		// No redirect: the server root is the context URL
+		return rawURL, nil
+	}
+
+	return location.String(), nil
+}
+
+// findCalendarColors reads the calendar-color property of every collection
+// in a calendar home set, keyed by collection path without trailing slash
+func findCalendarColors(ctx context.Context, httpClient *http.Client, homeSetURL string) (map[string]string, error) {
+	ms, err := propfind(ctx, httpClient, homeSetURL, "1", "<a:calendar-color/>")
+	if err != nil {
+		return nil, err
+	}
+
+	colors := make(map[string]string)
+	for _, resp := range ms.Responses {
+		p, err := resp.path()
+		if err != nil {
+			continue
+		}
+		if color := normalizeColor(resp.prop().CalendarColor); color != "" {
+			colors[strings.TrimSuffix(p, "/")] = color
+		}
+	}
+
+	return colors, nil
+}
+
+// normalizeColor converts a calendar-color value to #RRGGBB, dropping the
+// alpha channel some servers append. Unsupported values yield "".
+func normalizeColor(color string) string {
+	color = strings.TrimSpace(color)
+	if !strings.HasPrefix(color, "#") {
+		return ""
+	}
+	switch len(color) {
+	case 7:
+		return strings.ToUpper(color)
+	case 9:
+		return strings.ToUpper(color[:7])
+	default:
+		return ""
+	}
+}
+
+// supportsObjects reports whether a calendar collection can hold events or
+// tasks, so that task lists, such as those of Nextcloud Tasks or iCloud
+// Reminders, are discovered too. Collections not advertising their
+// supported components accept any.
+func supportsObjects(cal caldav.Calendar) bool {
+	if len(cal.SupportedComponentSet) == 0 {
+		return true
+	}
+	for _, comp := range cal.SupportedComponentSet {
+		if strings.EqualFold(comp, "VEVENT") || strings.EqualFold(comp, "VTODO") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/caldavtest"
+	"github.com/mano/mucal/internal/config"
+)
+
+// testAccount returns the configuration of the account of the server
+func testAccount(t *testing.T, server *caldavtest.Server) config.Account {
+	t.Helper()
+
+	passwordFile := filepath.Join(t.TempDir(), "password.txt")
+	if err := os.WriteFile(passwordFile, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return config.Account{
+		Name:         "server",
+		URL:          server.Server.URL,
+		UserID:       "user",
+		PasswordFile: passwordFile,
+	}
+}
+
+func TestDiscoverCalendars(t *testing.T) {
+	server := caldavtest.NewServer(t, "")
+	for _, name := range []string{"personal", "work", "shared-team", "shared-family"} {
+		server.AddCalendar(name)
+	}
+
+	tests := []struct {
+		name string
+		edit func(*config.Account)
+		want []config.Calendar
+	}{
+		{
+			name: "all",
+			edit: func(acc *config.Account) { acc.Color = "#FF6B6B" },
+			want: []config.Calendar{
+				{Name: "personal", Color: "#FF6B6B"},
+				{Name: "shared-family", Color: "#FF6B6B"},
+				{Name: "shared-team", Color: "#FF6B6B"},
+				{Name: "work", Color: "#FF6B6B"},
+			},
+		},
+		{
+			name: "include and exclude",
+			edit: func(acc *config.Account) {
+				acc.Include = []string{"shared-*", "work"}
+				acc.Exclude = []string{"*-family"}
+			},
+			want: []config.Calendar{
+				{Name: "shared-team", Color: defaultColor},
+				{Name: "work", Color: defaultColor},
+			},
+		},
+		{
+			name: "overrides",
+			edit: func(acc *config.Account) {
+				acc.Include = []string{"personal", "work"}
+				acc.Overrides = map[string]config.CalendarOverride{
+					"personal": {Color: "#45B7D1"},
+					"work":     {Name: "Office", Color: "#4ECDC4"},
+				}
+			},
+			want: []config.Calendar{
+				{Name: "personal", Color: "#45B7D1"},
+				{Name: "Office", Color: "#4ECDC4"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := testAccount(t, server)
+			tt.edit(&acc)
+
+			calendars, err := DiscoverCalendars(context.Background(), &acc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Discovered calendars are found through the principal and its
+			// home set, and share the account credentials
+			var got []config.Calendar
+			for _, cal := range calendars {
+				if cal.UserID != acc.UserID || cal.PasswordFile != acc.PasswordFile || cal.Account != acc.Name {
+					t.Errorf("calendar %s: user %q, password file %q, account %q, want those of the account", cal.Name, cal.UserID, cal.PasswordFile, cal.Account)
+				}
+				got = append(got, config.Calendar{Name: cal.Name, Color: cal.Color})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("calendars = %+v, want %+v", got, tt.want)
+			}
+			for _, cal := range calendars {
+				if cal.Name == "Office" && cal.URL != server.URL("work") {
+					t.Errorf("URL of the renamed calendar = %s, want %s", cal.URL, server.URL("work"))
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverCalendarsComponents(t *testing.T) {
+	server := caldavtest.NewServer(t, "")
+	server.AddCalendar("events", ical.CompEvent)
+	server.AddCalendar("tasks", ical.CompToDo)
+	server.AddCalendar("journal", ical.CompJournal)
+	acc := testAccount(t, server)
+
+	calendars, err := DiscoverCalendars(context.Background(), &acc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Task lists are calendars too, journals are not
+	var names []string
+	for _, cal := range calendars {
+		names = append(names, cal.Name)
+	}
+	if want := []string{"events", "tasks"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("calendars = %v, want %v", names, want)
+	}
+}
+
+func TestDiscoverCalendarsTimeout(t *testing.T) {
+	server := caldavtest.NewServer(t, "")
+	server.AddCalendar("personal")
+	acc := testAccount(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if _, err := DiscoverCalendars(ctx, &acc); err == nil {
+		t.Error("discovery succeeded after its context expired")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+
+	"github.com/emersion/go-webdav"
+)
+
+// Causes of sync errors, as reported by ErrorCause
+const (
+	CauseAuth    = "auth"
+	CauseTimeout = "timeout"
+	CauseParse   = "parse"
+	CauseNetwork = "network"
+	CauseOther   = "other"
+)
+
+// StatusError reports an unexpected HTTP status from a server
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected status " + e.Status
+}
+
+// parseError marks errors caused by invalid calendar data
+type parseError struct {
+	err error
+}
+
+func (e *parseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+// webdavErrorType is the type of the HTTP errors of go-webdav, which it
+// does not export
+var webdavErrorType = reflect.TypeOf(webdav.NewHTTPError(0, nil))
+
+// ErrorCause classifies a sync error as an authentication failure, a
+// timeout, invalid calendar data, a network failure or another error
+func ErrorCause(err error) string {
+	var netErr net.Error
+	switch code := httpStatus(err); {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return CauseAuth
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return CauseTimeout
+	}
+
+	var parseErr *parseError
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &parseErr) || errors.As(err, &syntaxErr) {
+		return CauseParse
+	}
+	if errors.As(err, &netErr) {
+		return CauseNetwork
+	}
+	return CauseOther
+}
+
+// ErrorSummary describes a sync error by its cause, and the HTTP status the
+// server answered with if any. Unlike the error itself, it holds no URL or
+// calendar name, so that it can be shown to anyone who may see the calendar.
+func ErrorSummary(err error) string {
+	if code := httpStatus(err); code != 0 {
+		return fmt.Sprintf("calendar unavailable (%s, HTTP %d)", ErrorCause(err), code)
+	}
+	return fmt.Sprintf("calendar unavailable (%s)", ErrorCause(err))
+}
+
+// httpStatus returns the HTTP status reported by an error, or zero
+func httpStatus(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code
+	}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		if reflect.TypeOf(err) == webdavErrorType {
+			return int(reflect.ValueOf(err).Elem().FieldByName("Code").Int())
+		}
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/emersion/go-webdav"
+)
+
+func TestErrorCause(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "status", err: fmt.Errorf("failed to download calendar: %w", &StatusError{Code: http.StatusUnauthorized, Status: "401 Unauthorized"}), want: CauseAuth},
+		{name: "go-webdav status", err: fmt.Errorf("failed to list calendar: %w", webdav.NewHTTPError(http.StatusForbidden, nil)), want: CauseAuth},
+		{name: "server error", err: webdav.NewHTTPError(http.StatusInternalServerError, nil), want: CauseOther},
+		{name: "deadline", err: fmt.Errorf("failed to sync: %w", context.DeadlineExceeded), want: CauseTimeout},
+		{name: "parse", err: fmt.Errorf("failed to parse calendar: %w", &parseError{errors.New("ical: malformed")}), want: CauseParse},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: CauseNetwork},
+		{name: "other", err: errors.New("boom"), want: CauseOther},
+	}
+
+	for _, tt := range tests {
+		if got := ErrorCause(tt.err); got != tt.want {
+			t.Errorf("%s: ErrorCause(%v) = %s, want %s", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestErrorSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "status", err: fmt.Errorf("failed to download calendar secret: %w", &StatusError{Code: http.StatusNotFound, Status: "404 Not Found"}), want: "calendar unavailable (other, HTTP 404)"},
+		{name: "go-webdav status", err: fmt.Errorf("failed to query calendar secret: %w", webdav.NewHTTPError(http.StatusUnauthorized, nil)), want: "calendar unavailable (auth, HTTP 401)"},
+		{name: "network", err: &url.Error{Op: "Get", URL: "https://example.com/feed?key=secret", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, want: "calendar unavailable (network)"},
+	}
+
+	for _, tt := range tests {
+		if got := ErrorSummary(tt.err); got != tt.want {
+			t.Errorf("%s: ErrorSummary(%v) = %q, want %q", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -20,16 +20,41 @@ import (
 
 // Event represents a calendar event
 type Event struct {
-	UID          string    `json:"uid"`
-	Summary      string    `json:"summary"`
-	Description  string    `json:"description"`
-	Location     string    `json:"location"`
-	Start        time.Time `json:"start"`
-	End          time.Time `json:"end"`
-	AllDay       bool      `json:"allDay"`
-	CalendarName string    `json:"calendarName"`
-	CalendarColor string   `json:"calendarColor"`
-	IsRecurring  bool      `json:"isRecurring"`
+	UID           string     `json:"uid"`
+	Summary       string     `json:"summary"`
+	Description   string     `json:"description"`
+	Location      string     `json:"location"`
+	Start         time.Time  `json:"start"`
+	End           time.Time  `json:"end"`
+	AllDay        bool       `json:"allDay"`
+	Status        string     `json:"status"`
+	Transparency  string     `json:"transparency"`
+	Class         string     `json:"class"`
+	Categories    []string   `json:"categories,omitempty"`
+	URL           string     `json:"url"`
+	Priority      int        `json:"priority"`
+	Organizer     *Attendee  `json:"organizer,omitempty"`
+	Attendees     []Attendee `json:"attendees,omitempty"`
+	CalendarName  string     `json:"calendarName"`
+	CalendarColor string     `json:"calendarColor"`
+	IsRecurring   bool       `json:"isRecurring"`
+}
+
+// Attendee is the organizer or an attendee of an event
+type Attendee struct {
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+// overlaps reports whether the event overlaps the [start, end) range, with
+// zero-duration events treated as instants (RFC 4791 section 9.9)
+func (e *Event) overlaps(start, end time.Time) bool {
+	if e.End.After(e.Start) {
+		return e.Start.Before(end) && e.End.After(start)
+	}
+	return !e.Start.Before(start) && e.Start.Before(end)
 }
 
 // Events is a slice of Event pointers with sorting capabilities
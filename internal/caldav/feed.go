@@ -0,0 +1,151 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/version"
+)
+
+// Feed merges the objects of several calendars into a single iCalendar
+// document. Events are kept as stored on the server, with all of their
+// properties and alarms, recurrence rules, exceptions and overrides, rather
+// than expanded into instances.
+type Feed struct {
+	cal       *ical.Calendar
+	timezones []*ical.Component
+	events    []*ical.Component
+	tzids     map[string]bool
+
+	// The TZIDs the events refer to, the time zone of those that resolve
+	// to none, and the start of the earliest event
+	referenced map[string]bool
+	fallback   *time.Location
+	since      time.Time
+}
+
+// NewFeed creates an empty feed with the given name. Events in unknown time
+// zones are described in the fallback one, in which they are shown.
+func NewFeed(name string, fallback *time.Location) *Feed {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, "-//mucal//μCal "+version.Version+"//EN")
+	cal.Props.SetText("X-WR-CALNAME", name)
+	cal.Props.Get("X-WR-CALNAME").Params.Del(ical.ParamValue)
+
+	return &Feed{
+		cal:        cal,
+		tzids:      make(map[string]bool),
+		referenced: make(map[string]bool),
+		fallback:   fallback,
+	}
+}
+
+// Add adds the events of a calendar's objects to the feed, along with the
+// time zones they use. When start and end are not zero, only objects with
+// events overlapping that range are added.
+func (f *Feed) Add(ctx context.Context, src Source, objects []CalendarObject, start, end time.Time) {
+	stamp := time.Now().UTC()
+
+	for i := range objects {
+		obj := &objects[i]
+		if obj.Data == nil {
+			continue
+		}
+
+		span, ok := src.ObjectSpan(ctx, obj)
+		if !ok {
+			continue
+		}
+		if !start.IsZero() && !end.IsZero() && !span.Overlaps(start, end) {
+			continue
+		}
+		if f.since.IsZero() || span.Start.Before(f.since) {
+			f.since = span.Start
+		}
+
+		for _, comp := range obj.Data.Children {
+			switch comp.Name {
+			case ical.CompTimezone:
+				tzid := comp.Props.Get(ical.PropTimezoneID)
+				if tzid == nil || f.tzids[tzid.Value] {
+					continue
+				}
+				f.tzids[tzid.Value] = true
+				f.timezones = append(f.timezones, comp)
+			case ical.CompEvent:
+				f.events = append(f.events, withStamp(comp, stamp))
+				for _, props := range comp.Props {
+					for _, prop := range props {
+						if tzid := prop.Params.Get(ical.PropTimezoneID); tzid != "" {
+							f.referenced[tzid] = true
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// Encode writes the feed as an iCalendar document. Time zones are written
+// before the events referring to them. Those no object defines, as is
+// common with local calendars and subscriptions, are generated from the
+// time zone their TZID resolves to.
+func (f *Feed) Encode(w io.Writer) error {
+	var missing []string
+	for tzid := range f.referenced {
+		if !f.tzids[tzid] {
+			missing = append(missing, tzid)
+		}
+	}
+	sort.Strings(missing)
+
+	timezones := append([]*ical.Component{}, f.timezones...)
+	for _, tzid := range missing {
+		loc := lookupTZID(tzid)
+		if loc == nil {
+			loc = f.fallback
+		}
+		timezones = append(timezones, generateTimezone(tzid, loc, f.since))
+	}
+
+	f.cal.Children = append(timezones, f.events...)
+	return ical.NewEncoder(w).Encode(f.cal)
+}
+
+// withStamp returns the event with a DTSTAMP, which RFC 5545 requires but
+// the server may not have returned. The cached component is not modified.
+func withStamp(comp *ical.Component, stamp time.Time) *ical.Component {
+	if comp.Props.Get(ical.PropDateTimeStamp) != nil {
+		return comp
+	}
+
+	props := make(ical.Props, len(comp.Props)+1)
+	for name, values := range comp.Props {
+		props[name] = values
+	}
+	props.SetDateTime(ical.PropDateTimeStamp, stamp)
+
+	return &ical.Component{
+		Name:     comp.Name,
+		Props:    props,
+		Children: comp.Children,
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/config"
+)
+
+// encodeFeed adds the objects to a feed falling back to Europe/Rome, and
+// decodes the document it encodes
+func encodeFeed(t *testing.T, objects ...CalendarObject) *ical.Calendar {
+	t.Helper()
+
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := NewSource(&config.Calendar{Name: "test", Type: config.TypeFile, Path: "test.ics"}, rome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed := NewFeed("test", rome)
+	feed.Add(context.Background(), source, objects, time.Time{}, time.Time{})
+
+	var buf bytes.Buffer
+	if err := feed.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	cal, err := ical.NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("invalid feed: %v", err)
+	}
+	return cal
+}
+
+// feedTimezones returns the VTIMEZONE components of a feed by TZID
+func feedTimezones(t *testing.T, cal *ical.Calendar) map[string]*ical.Component {
+	t.Helper()
+
+	timezones := make(map[string]*ical.Component)
+	for _, comp := range cal.Children {
+		if comp.Name != ical.CompTimezone {
+			continue
+		}
+		tzid := textProp(comp, ical.PropTimezoneID)
+		if timezones[tzid] != nil {
+			t.Errorf("time zone %s defined twice", tzid)
+		}
+		timezones[tzid] = comp
+	}
+	return timezones
+}
+
+func TestFeedGeneratedTimezones(t *testing.T) {
+	tests := []struct {
+		tzid string
+		// zone is the IANA zone the TZID stands for
+		zone string
+	}{
+		{tzid: "Europe/Rome", zone: "Europe/Rome"},
+		{tzid: "America/New_York", zone: "America/New_York"},
+		{tzid: "Australia/Sydney", zone: "Australia/Sydney"},
+		{tzid: "Asia/Tokyo", zone: "Asia/Tokyo"},
+		// Without daylight saving time since 2019
+		{tzid: "America/Sao_Paulo", zone: "America/Sao_Paulo"},
+		// With irregular changes around Ramadan
+		{tzid: "Africa/Casablanca", zone: "Africa/Casablanca"},
+		{tzid: "W. Europe Standard Time", zone: "Europe/Berlin"},
+		// Unknown zones are those the events are shown in
+		{tzid: "Office Time", zone: "Europe/Rome"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tzid, func(t *testing.T) {
+			obj := weeklyEvent(t, tt.tzid, "")
+			obj.Data.Children[0].Props.Get(ical.PropDateTimeStart).Value = "20150105T090000"
+
+			comp := feedTimezones(t, encodeFeed(t, obj))[tt.tzid]
+			if comp == nil {
+				t.Fatalf("no time zone generated for %s", tt.tzid)
+			}
+			loc, err := locationFromVTimezone(tt.tzid, comp)
+			if err != nil {
+				t.Fatalf("invalid time zone: %v", err)
+			}
+			want, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Every hour from the first event on has the offset of the
+			// zone, including beyond the years listed explicitly
+			for _, span := range [][2]int{{2015, 2030}, {2060, 2062}} {
+				end := time.Date(span[1], 1, 1, 0, 0, 0, 0, time.UTC)
+				for instant := time.Date(span[0], 1, 1, 0, 0, 0, 0, time.UTC); instant.Before(end); instant = instant.Add(time.Hour) {
+					_, gotOffset := instant.In(loc).Zone()
+					_, wantOffset := instant.In(want).Zone()
+					if gotOffset != wantOffset {
+						t.Fatalf("offset at %s = %d, want %d", instant, gotOffset, wantOffset)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFeedTimezoneRules(t *testing.T) {
+	comp := feedTimezones(t, encodeFeed(t, weeklyEvent(t, "Europe/Rome", "")))["Europe/Rome"]
+
+	var rules []string
+	for _, observance := range comp.Children {
+		for _, name := range []string{ical.PropDateTimeStart, ical.PropTimezoneOffsetFrom, ical.PropTimezoneOffsetTo} {
+			if params := observance.Props.Get(name).Params; len(params) != 0 {
+				t.Errorf("%s parameters = %v, want none", name, params)
+			}
+		}
+		if rrule := observance.Props.Get(ical.PropRecurrenceRule); rrule != nil {
+			rules = append(rules, observance.Name+" "+rrule.Value)
+		}
+	}
+	want := "DAYLIGHT FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU, STANDARD FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU"
+	if strings.Join(rules, ", ") != want {
+		t.Errorf("rules = %v, want %s", rules, want)
+	}
+}
+
+func TestFeedDefinedTimezones(t *testing.T) {
+	// Time zones defined by the objects are kept as they are
+	cal := encodeFeed(t, weeklyEvent(t, "Office Time", customTimezone), weeklyEvent(t, "Office Time", ""))
+
+	timezones := feedTimezones(t, cal)
+	if len(timezones) != 1 || textProp(timezones["Office Time"], ical.PropTimezoneName) != "" {
+		t.Fatalf("time zones = %v, want the defined one only", timezones)
+	}
+	if len(timezones["Office Time"].Children) != 2 {
+		t.Errorf("observances = %d, want those of the definition", len(timezones["Office Time"].Children))
+	}
+}
+
+func TestFeedProperties(t *testing.T) {
+	obj := weeklyEvent(t, "Europe/Rome", "")
+	event := obj.Data.Children[0]
+	event.Props.SetText(ical.PropSequence, "3")
+	event.Props.SetText("X-MUCAL-TEST", "kept")
+	alarm := ical.NewComponent(ical.CompAlarm)
+	alarm.Props.SetText(ical.PropAction, "DISPLAY")
+	alarm.Props.SetText(ical.PropTrigger, "-PT15M")
+	alarm.Props.SetText(ical.PropDescription, "Reminder")
+	event.Children = append(event.Children, alarm)
+
+	// Events are exported with every property and alarm
+	for _, comp := range encodeFeed(t, obj).Children {
+		if comp.Name != ical.CompEvent {
+			continue
+		}
+		if got := textProp(comp, ical.PropDateTimeStamp); got != "20260901T000000Z" {
+			t.Errorf("DTSTAMP = %q, want the stored one", got)
+		}
+		if textProp(comp, ical.PropSequence) != "3" || textProp(comp, "X-MUCAL-TEST") != "kept" {
+			t.Errorf("properties = %v, want SEQUENCE and X-MUCAL-TEST kept", comp.Props)
+		}
+		if len(comp.Children) != 1 || comp.Children[0].Name != ical.CompAlarm {
+			t.Errorf("children = %v, want the alarm", comp.Children)
+		}
+	}
+}
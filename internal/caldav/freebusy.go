@@ -0,0 +1,129 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/version"
+)
+
+// Free/busy types of RFC 5545
+const (
+	BusyTypeBusy      = "BUSY"
+	BusyTypeTentative = "BUSY-TENTATIVE"
+)
+
+// BusyPeriod is a time range during which a calendar is busy, with the
+// events causing it when they may be disclosed
+type BusyPeriod struct {
+	Start  time.Time   `json:"start"`
+	End    time.Time   `json:"end"`
+	Type   string      `json:"type"`
+	Events []BusyEvent `json:"events,omitempty"`
+}
+
+// BusyEvent identifies an event within a busy period
+type BusyEvent struct {
+	Summary      string `json:"summary"`
+	CalendarName string `json:"calendarName"`
+}
+
+// BusyPeriod returns the time blocked by the event, with its title if
+// details is true. It reports false for cancelled, transparent and
+// zero-duration events.
+func (e *Event) BusyPeriod(details bool) (BusyPeriod, bool) {
+	if e.Status == "CANCELLED" || e.Transparency == "TRANSPARENT" || !e.End.After(e.Start) {
+		return BusyPeriod{}, false
+	}
+
+	period := BusyPeriod{Start: e.Start, End: e.End, Type: BusyTypeBusy}
+	if e.Status == "TENTATIVE" {
+		period.Type = BusyTypeTentative
+	}
+	if details {
+		period.Events = []BusyEvent{{Summary: e.Summary, CalendarName: e.CalendarName}}
+	}
+	return period, true
+}
+
+// MergeBusy clips periods to [start, end) and merges those overlapping or
+// adjacent, in start order. A merged period is tentative only if all of its
+// parts are.
+func MergeBusy(periods []BusyPeriod, start, end time.Time) []BusyPeriod {
+	var clipped []BusyPeriod
+	for _, p := range periods {
+		if p.Start.Before(start) {
+			p.Start = start
+		}
+		if p.End.After(end) {
+			p.End = end
+		}
+		if p.End.After(p.Start) {
+			clipped = append(clipped, p)
+		}
+	}
+
+	sort.SliceStable(clipped, func(i, j int) bool {
+		return clipped[i].Start.Before(clipped[j].Start)
+	})
+
+	var merged []BusyPeriod
+	for _, p := range clipped {
+		if n := len(merged); n > 0 && !p.Start.After(merged[n-1].End) {
+			last := &merged[n-1]
+			if p.End.After(last.End) {
+				last.End = p.End
+			}
+			if p.Type == BusyTypeBusy {
+				last.Type = BusyTypeBusy
+			}
+			last.Events = append(last.Events, p.Events...)
+			continue
+		}
+		p.Events = append([]BusyEvent(nil), p.Events...)
+		merged = append(merged, p)
+	}
+
+	return merged
+}
+
+// EncodeFreeBusy writes busy periods as an iCalendar document holding a
+// single VFREEBUSY component for [start, end). Event details are left out.
+func EncodeFreeBusy(w io.Writer, periods []BusyPeriod, start, end time.Time) error {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, "-//mucal//μCal "+version.Version+"//EN")
+	cal.Props.SetText(ical.PropMethod, "PUBLISH")
+
+	fb := ical.NewComponent(ical.CompFreeBusy)
+	fb.Props.SetText(ical.PropUID, "freebusy-"+start.UTC().Format("20060102T150405Z")+"-"+end.UTC().Format("20060102T150405Z")+"@mucal")
+	fb.Props.SetDateTime(ical.PropDateTimeStamp, time.Now().UTC())
+	fb.Props.SetDateTime(ical.PropDateTimeStart, start.UTC())
+	fb.Props.SetDateTime(ical.PropDateTimeEnd, end.UTC())
+
+	for _, p := range periods {
+		prop := ical.NewProp(ical.PropFreeBusy)
+		prop.Params.Set(ical.ParamFreeBusyType, p.Type)
+		prop.Value = p.Start.UTC().Format("20060102T150405Z") + "/" + p.End.UTC().Format("20060102T150405Z")
+		fb.Props.Add(prop)
+	}
+
+	cal.Children = append(cal.Children, fb)
+	return ical.NewEncoder(w).Encode(cal)
+}
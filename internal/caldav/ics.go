@@ -0,0 +1,221 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/config"
+)
+
+// icsSource is the source of a plain iCalendar file served over HTTP, such
+// as a webcal:// subscription
+type icsSource struct {
+	*SourceBase
+	httpClient *http.Client
+
+	// Validators of the last download
+	etag         string
+	lastModified string
+}
+
+// newICSSource creates the source of a plain iCalendar file. Credentials
+// are optional.
+func newICSSource(cal *config.Calendar, tz *time.Location) (Source, error) {
+	httpClient := newAnonymousHTTPClient()
+	if cal.PasswordFile != "" {
+		password, err := cal.GetPassword()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get password for calendar %s: %w", cal.Name, err)
+		}
+		httpClient = newHTTPClient(cal.UserID, password)
+	}
+
+	return &icsSource{
+		SourceBase: NewSourceBase(cal, tz),
+		httpClient: httpClient,
+	}, nil
+}
+
+// icsURL returns the download URL, mapping webcal:// to https://
+func (c *icsSource) icsURL() string {
+	if rest, ok := strings.CutPrefix(c.calendar.URL, "webcal://"); ok {
+		return "https://" + rest
+	}
+	return c.calendar.URL
+}
+
+// Sync downloads the iCalendar file with a conditional GET, so that an
+// unchanged file is not transferred again
+func (c *icsSource) Sync(ctx context.Context) ([]CalendarObject, []DateRange, error) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.icsURL(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request for %s: %w", c.calendar.Name, err)
+	}
+	req.Header.Set("Accept", "text/calendar")
+	if c.state.loaded {
+		if c.etag != "" {
+			req.Header.Set("If-None-Match", c.etag)
+		}
+		if c.lastModified != "" {
+			req.Header.Set("If-Modified-Since", c.lastModified)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download calendar %s: %w", c.calendar.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && c.state.loaded {
+		return c.state.list(), nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to download calendar %s: %w", c.calendar.Name, &StatusError{Code: resp.StatusCode, Status: resp.Status})
+	}
+
+	// Read one byte more than allowed to detect oversized files
+	maxSize := c.calendar.GetMaxSize()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download calendar %s: %w", c.calendar.Name, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, nil, fmt.Errorf("calendar %s exceeds the size limit of %d bytes", c.calendar.Name, maxSize)
+	}
+
+	objects, err := splitCalendarData(c.calendar.URL, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse calendar %s: %w", c.calendar.Name, &parseError{err})
+	}
+
+	changed := c.storeObjects(ctx, objects)
+	c.etag = resp.Header.Get("ETag")
+	c.lastModified = resp.Header.Get("Last-Modified")
+
+	return c.state.list(), changed, nil
+}
+
+// Adopt takes over the objects and download validators of a previous
+// source of the file
+func (c *icsSource) Adopt(old Source) {
+	if o, ok := old.(*icsSource); ok {
+		c.adoptState(o.SourceBase, func() {
+			c.etag, c.lastModified = o.etag, o.lastModified
+		})
+	}
+}
+
+// splitCalendarData parses an iCalendar stream, which may hold several
+// calendars, into one object per UID, as a CalDAV server would store them.
+// Each object carries the time zones of its calendar and an ETag computed
+// from its contents, so that changes can be detected.
+func splitCalendarData(base string, data []byte) (map[string]CalendarObject, error) {
+	groups := make(map[string]*ical.Calendar)
+
+	dec := ical.NewDecoder(bytes.NewReader(data))
+	for {
+		cal, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var timezones []*ical.Component
+		for _, comp := range cal.Children {
+			if comp.Name == ical.CompTimezone {
+				timezones = append(timezones, comp)
+			}
+		}
+
+		for _, comp := range cal.Children {
+			if comp.Name != ical.CompEvent && comp.Name != ical.CompToDo {
+				continue
+			}
+			uid := comp.Props.Get(ical.PropUID)
+			if uid == nil {
+				continue
+			}
+
+			group, ok := groups[uid.Value]
+			if !ok {
+				group = ical.NewCalendar()
+				group.Props = cal.Props
+				group.Children = append(group.Children, timezones...)
+				groups[uid.Value] = group
+			}
+			group.Children = append(group.Children, comp)
+		}
+	}
+
+	objects := make(map[string]CalendarObject, len(groups))
+	for uid, cal := range groups {
+		path := base + "#" + uid
+		objects[path] = CalendarObject{
+			Path: path,
+			ETag: contentETag(cal),
+			Data: cal,
+		}
+	}
+
+	return objects, nil
+}
+
+// contentETag hashes the encoded calendar, regardless of the DTSTAMP of its
+// components: feeds generated on the fly set it to the time of the
+// download, and some leave it out. Calendars that cannot be encoded get an
+// empty ETag, and are thus always considered changed.
+func contentETag(cal *ical.Calendar) string {
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(&ical.Calendar{Component: unstamped(cal.Component)}); err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// unstamped returns a copy of a component whose events, tasks and journal
+// entries have the same DTSTAMP, which they must have to be encoded
+func unstamped(comp *ical.Component) *ical.Component {
+	c := &ical.Component{Name: comp.Name, Props: make(ical.Props, len(comp.Props))}
+	for name, props := range comp.Props {
+		c.Props[name] = props
+	}
+	switch comp.Name {
+	case ical.CompEvent, ical.CompToDo, ical.CompJournal:
+		c.Props.SetDateTime(ical.PropDateTimeStamp, time.Unix(0, 0).UTC())
+	}
+
+	for _, child := range comp.Children {
+		c.Children = append(c.Children, unstamped(child))
+	}
+	return c
+}
@@ -0,0 +1,259 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/config"
+)
+
+// feedServer serves an iCalendar feed, honoring If-None-Match when it is
+// given an ETag
+type feedServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	body        string
+	etag        string
+	requests    int
+	notModified int
+}
+
+// newFeedServer starts a feed server, closed when the test ends
+func newFeedServer(t *testing.T, body string) *feedServer {
+	t.Helper()
+
+	s := &feedServer{body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.requests++
+		if s.etag != "" {
+			if r.Header.Get("If-None-Match") == s.etag {
+				s.notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", s.etag)
+		}
+		w.Header().Set("Content-Type", "text/calendar")
+		w.Write([]byte(s.body))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// serve replaces the feed and its ETag
+func (s *feedServer) serve(body, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.etag = body, etag
+}
+
+// newFeedSource creates the source of a feed with the given size limit, or
+// the default one if zero
+func newFeedSource(t *testing.T, url string, maxSize int64) Source {
+	t.Helper()
+
+	source, err := NewSource(&config.Calendar{Name: "feed", Type: config.TypeICS, URL: url, Color: "#4ECDC4", MaxSize: maxSize}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+// feed returns a calendar holding the given components, stamped at the
+// given time as feeds generated on the fly are
+func feed(stamp string, components ...string) string {
+	data := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//mucal//tests//EN\r\n"
+	for _, comp := range components {
+		data += strings.ReplaceAll(comp, "DTSTAMP\r\n", "DTSTAMP:"+stamp+"\r\n")
+	}
+	return data + "END:VCALENDAR\r\n"
+}
+
+// feedEvent returns a one-hour event in Rome, with a DTSTAMP filled in by
+// feed
+func feedEvent(uid, summary, start string) string {
+	return "BEGIN:VEVENT\r\n" +
+		"UID:" + uid + "\r\n" +
+		"DTSTAMP\r\n" +
+		"DTSTART;TZID=Europe/Rome:" + start + "\r\n" +
+		"DURATION:PT1H\r\n" +
+		"SUMMARY:" + summary + "\r\n" +
+		"END:VEVENT\r\n"
+}
+
+// romeTimezone is the VTIMEZONE of the events of the feeds
+const romeTimezone = "BEGIN:VTIMEZONE\r\n" +
+	"TZID:Europe/Rome\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:19961027T030000\r\n" +
+	"TZOFFSETFROM:+0200\r\n" +
+	"TZOFFSETTO:+0100\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\n" +
+	"END:STANDARD\r\n" +
+	"BEGIN:DAYLIGHT\r\n" +
+	"DTSTART:19810329T020000\r\n" +
+	"TZOFFSETFROM:+0100\r\n" +
+	"TZOFFSETTO:+0200\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\n" +
+	"END:DAYLIGHT\r\n" +
+	"END:VTIMEZONE\r\n"
+
+func TestICSConditionalGet(t *testing.T) {
+	server := newFeedServer(t, feed("20261001T000000Z", feedEvent("a", "First", "20261005T090000")))
+	server.serve(server.body, `"v1"`)
+	source := newFeedSource(t, server.URL, 0)
+
+	got, _ := syncSummaries(t, source)
+	if strings.Join(got, ", ") != "First" {
+		t.Errorf("first sync events = %v, want [First]", got)
+	}
+
+	// An unchanged feed is not downloaded again, and keeps its objects
+	got, changed := syncSummaries(t, source)
+	server.mu.Lock()
+	if server.notModified != 1 {
+		t.Errorf("%d of %d requests not modified, want 1", server.notModified, server.requests)
+	}
+	server.mu.Unlock()
+	if strings.Join(got, ", ") != "First" || len(changed) != 0 {
+		t.Errorf("unchanged sync events = %v, changes = %v, want [First] and none", got, changed)
+	}
+
+	server.serve(feed("20261001T000000Z", feedEvent("a", "Second", "20261012T090000")), `"v2"`)
+	got, changed = syncSummaries(t, source)
+	if strings.Join(got, ", ") != "Second" || len(changed) == 0 {
+		t.Errorf("sync of the changed feed events = %v, changes = %v, want [Second] and some", got, changed)
+	}
+}
+
+func TestICSMaxSize(t *testing.T) {
+	body := feed("20261001T000000Z", feedEvent("a", "First", "20261005T090000"))
+	server := newFeedServer(t, body)
+
+	if _, _, err := newFeedSource(t, server.URL, int64(len(body))-1).Sync(context.Background()); err == nil || !strings.Contains(err.Error(), "size limit") {
+		t.Errorf("sync of an oversized feed: error = %v, want the size limit", err)
+	}
+	if _, _, err := newFeedSource(t, server.URL, int64(len(body))).Sync(context.Background()); err != nil {
+		t.Errorf("sync of a feed at the size limit: %v", err)
+	}
+}
+
+func TestSplitCalendarData(t *testing.T) {
+	override := "BEGIN:VEVENT\r\n" +
+		"UID:standup\r\n" +
+		"DTSTAMP\r\n" +
+		"RECURRENCE-ID;TZID=Europe/Rome:20261006T090000\r\n" +
+		"DTSTART;TZID=Europe/Rome:20261006T110000\r\n" +
+		"DURATION:PT1H\r\n" +
+		"SUMMARY:Standup (moved)\r\n" +
+		"END:VEVENT\r\n"
+	task := "BEGIN:VTODO\r\n" +
+		"UID:task\r\n" +
+		"DTSTAMP\r\n" +
+		"SUMMARY:Task\r\n" +
+		"END:VTODO\r\n"
+	recurring := strings.Replace(feedEvent("standup", "Standup", "20261005T090000"), "DURATION", "RRULE:FREQ=DAILY;COUNT=3\r\nDURATION", 1)
+
+	// Feeds may concatenate several calendars, each with its time zones
+	data := feed("20261001T000000Z", romeTimezone, recurring, feedEvent("review", "Review", "20261007T150000")) +
+		feed("20261001T000000Z", romeTimezone, override, task)
+
+	objects, err := splitCalendarData("https://example.com/feed.ics", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	components := make(map[string][]string)
+	for path, obj := range objects {
+		if obj.Path != path || obj.ETag == "" {
+			t.Errorf("object %s: path %s, ETag %q", path, obj.Path, obj.ETag)
+		}
+		for _, comp := range obj.Data.Children {
+			components[path] = append(components[path], comp.Name)
+		}
+		sort.Strings(components[path])
+	}
+
+	want := map[string][]string{
+		"https://example.com/feed.ics#standup": {ical.CompEvent, ical.CompEvent, ical.CompTimezone},
+		"https://example.com/feed.ics#review":  {ical.CompEvent, ical.CompTimezone},
+		"https://example.com/feed.ics#task":    {ical.CompTimezone, ical.CompToDo},
+	}
+	if len(components) != len(want) {
+		t.Errorf("objects = %v, want %v", components, want)
+	}
+	for path, names := range want {
+		if strings.Join(components[path], " ") != strings.Join(names, " ") {
+			t.Errorf("components of %s = %v, want %v", path, components[path], names)
+		}
+	}
+
+	// The override of the recurring event is applied across calendars
+	source := newFeedSource(t, "https://example.com/feed.ics", 0)
+	var list []CalendarObject
+	for _, obj := range objects {
+		list = append(list, obj)
+	}
+	if got := summaries(source, list); strings.Join(got, ", ") != "Review, Standup, Standup, Standup (moved)" {
+		t.Errorf("events = %v, want [Review Standup Standup Standup (moved)]", got)
+	}
+}
+
+func TestICSContentETag(t *testing.T) {
+	events := []string{feedEvent("a", "First", "20261005T090000"), feedEvent("b", "Second", "20261006T090000")}
+	server := newFeedServer(t, feed("20261001T000000Z", events...))
+	source := newFeedSource(t, server.URL, 0)
+
+	if _, changed := syncSummaries(t, source); len(changed) != 1 {
+		t.Errorf("first sync changes = %v, want a single unbounded range", changed)
+	}
+
+	// Without validators, the feed is downloaded again but its objects are
+	// unchanged, even when generated anew
+	if _, changed := syncSummaries(t, source); len(changed) != 0 {
+		t.Errorf("sync of the same feed changes = %v, want none", changed)
+	}
+	server.serve(feed("20261002T000000Z", events...), "")
+	if _, changed := syncSummaries(t, source); len(changed) != 0 {
+		t.Errorf("sync of the regenerated feed changes = %v, want none", changed)
+	}
+
+	// Only the changed event is reported
+	server.serve(feed("20261002T000000Z", events[0], feedEvent("b", "Second (moved)", "20261013T090000")), "")
+	_, changed := syncSummaries(t, source)
+	for _, day := range []int{6, 13} {
+		start := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+		if !overlapsAny(changed, start, start.AddDate(0, 0, 1)) {
+			t.Errorf("changes %v do not cover October %d", changed, day)
+		}
+	}
+	if start := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC); overlapsAny(changed, start, start.AddDate(0, 0, 1)) {
+		t.Errorf("changes %v cover the unchanged event", changed)
+	}
+}
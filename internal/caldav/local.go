@@ -0,0 +1,210 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/logging"
+)
+
+// fileSource is the source of a local iCalendar file
+type fileSource struct {
+	*SourceBase
+
+	// Modification time and size of the file when it was last read
+	signature string
+}
+
+// newFileSource creates the source of a local iCalendar file
+func newFileSource(cal *config.Calendar, tz *time.Location) (Source, error) {
+	return &fileSource{SourceBase: NewSourceBase(cal, tz)}, nil
+}
+
+// Sync reads the iCalendar file. The file is polled: it is only read again
+// when its modification time or size changed.
+func (c *fileSource) Sync(ctx context.Context) ([]CalendarObject, []DateRange, error) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	info, err := os.Stat(c.calendar.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read calendar %s: %w", c.calendar.Name, err)
+	}
+
+	signature := statETag(info)
+	if c.state.loaded && signature == c.signature {
+		return c.state.list(), nil, nil
+	}
+
+	data, err := readLocalFile(c.calendar.Path, info, c.calendar.GetMaxSize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	objects, err := splitCalendarData(c.calendar.Path, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse calendar %s: %w", c.calendar.Name, &parseError{err})
+	}
+
+	changed := c.storeObjects(ctx, objects)
+	c.signature = signature
+
+	return c.state.list(), changed, nil
+}
+
+// Adopt takes over the objects and signature of a previous source of the
+// file
+func (c *fileSource) Adopt(old Source) {
+	if o, ok := old.(*fileSource); ok {
+		c.adoptState(o.SourceBase, func() { c.signature = o.signature })
+	}
+}
+
+// vdirSource is the source of a local vdir directory holding one .ics file
+// per item, such as one kept up to date by vdirsyncer
+type vdirSource struct {
+	*SourceBase
+}
+
+// newVdirSource creates the source of a local vdir directory
+func newVdirSource(cal *config.Calendar, tz *time.Location) (Source, error) {
+	return &vdirSource{SourceBase: NewSourceBase(cal, tz)}, nil
+}
+
+// Adopt takes over the items of a previous source of the directory
+func (c *vdirSource) Adopt(old Source) {
+	if o, ok := old.(*vdirSource); ok {
+		c.adoptState(o.SourceBase, nil)
+	}
+}
+
+// Sync reads the vdir directory. Like a CalDAV collection, only the items
+// whose modification time or size changed are read again, and removed files
+// are evicted. Items that cannot be read are left as they were.
+func (c *vdirSource) Sync(ctx context.Context) ([]CalendarObject, []DateRange, error) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	entries, err := os.ReadDir(c.calendar.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read calendar %s: %w", c.calendar.Name, err)
+	}
+
+	objects := make(map[string]CalendarObject, len(entries))
+	for _, entry := range entries {
+		// vdirsyncer writes items through hidden temporary files
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".ics") {
+			continue
+		}
+
+		path := filepath.Join(c.calendar.Path, name)
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed since the directory was listed
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read calendar %s: %w", c.calendar.Name, err)
+		}
+
+		etag := statETag(info)
+		if old, ok := c.state.objects[path]; ok && old.ETag == etag {
+			objects[path] = old
+			continue
+		}
+
+		obj, err := c.readVdirItem(path, info)
+		if err != nil {
+			// Log error but continue with the other items. An item being
+			// rewritten keeps its previous copy, so that it is not
+			// reported as deleted, and is read again on the next sync.
+			logging.FromContext(ctx).Warn("failed to read calendar item",
+				"calendar", c.calendar.Name, "href", path, "error", err)
+			if old, ok := c.state.objects[path]; ok {
+				objects[path] = old
+			}
+			continue
+		}
+		objects[path] = *obj
+	}
+
+	changed := c.storeObjects(ctx, objects)
+
+	return c.state.list(), changed, nil
+}
+
+// readVdirItem reads and parses a single vdir item
+func (c *vdirSource) readVdirItem(path string, info fs.FileInfo) (*CalendarObject, error) {
+	data, err := readLocalFile(path, info, c.calendar.GetMaxSize())
+	if err != nil {
+		return nil, err
+	}
+
+	cal, err := ical.NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, &parseError{err})
+	}
+
+	return &CalendarObject{
+		Path:          path,
+		ModTime:       info.ModTime(),
+		ContentLength: info.Size(),
+		ETag:          statETag(info),
+		Data:          cal,
+	}, nil
+}
+
+// readLocalFile reads a local file, enforcing the given size limit
+func readLocalFile(path string, info fs.FileInfo, maxSize int64) ([]byte, error) {
+	if info.Size() > maxSize {
+		return nil, fmt.Errorf("%s exceeds the size limit of %d bytes", path, maxSize)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	// The file may have grown since it was stat'ed
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%s exceeds the size limit of %d bytes", path, maxSize)
+	}
+
+	return data, nil
+}
+
+// statETag derives an ETag from the modification time and size of a file,
+// as vdirsyncer does for its filesystem storage
+func statETag(info fs.FileInfo) string {
+	return fmt.Sprintf("%d;%d", info.ModTime().UnixNano(), info.Size())
+}
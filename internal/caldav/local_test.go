@@ -0,0 +1,179 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mano/mucal/internal/config"
+)
+
+// newLocalSource creates the source of a local calendar of the given type
+func newLocalSource(t *testing.T, typ, path string) Source {
+	t.Helper()
+
+	source, err := NewSource(&config.Calendar{Name: "local", Type: typ, Path: path, Color: "#4ECDC4"}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+// writeFile writes a file, failing the test on error
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// scramble overwrites a file with garbage of the same size and modification
+// time, which only a source reading it again would notice
+func scramble(t *testing.T, path string) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, strings.Repeat("x", int(info.Size())))
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// syncSummaries syncs a source, returning the summaries of its events and
+// the changes it reports
+func syncSummaries(t *testing.T, source Source) ([]string, []DateRange) {
+	t.Helper()
+
+	objects, changed, err := source.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	return summaries(source, objects), changed
+}
+
+func TestVdirSource(t *testing.T) {
+	dir := t.TempDir()
+	item := func(name string) string { return filepath.Join(dir, name) }
+
+	writeFile(t, item("a.ics"), testEvent("a", "First", "20261005T090000Z"))
+	writeFile(t, item("b.ics"), testEvent("b", "Second", "20261006T090000Z"))
+	// vdirsyncer writes items through hidden temporary files
+	writeFile(t, item(".c.ics.tmp"), testEvent("c", "Hidden", "20261007T090000Z"))
+	writeFile(t, item("notes.txt"), "not an item")
+
+	source := newLocalSource(t, config.TypeVdir, dir)
+
+	got, changed := syncSummaries(t, source)
+	if strings.Join(got, ", ") != "First, Second" {
+		t.Errorf("first sync events = %v, want [First Second]", got)
+	}
+	if len(changed) != 1 || changed[0] != (DateRange{}) {
+		t.Errorf("first sync changes = %v, want a single unbounded range", changed)
+	}
+
+	// Items whose modification time and size are unchanged are not read
+	// again
+	scramble(t, item("a.ics"))
+	got, changed = syncSummaries(t, source)
+	if strings.Join(got, ", ") != "First, Second" || len(changed) != 0 {
+		t.Errorf("unchanged sync events = %v, changes = %v, want [First Second] and none", got, changed)
+	}
+
+	// One item modified, one deleted and one added
+	writeFile(t, item("a.ics"), testEvent("a", "First (moved)", "20261012T090000Z"))
+	if err := os.Remove(item("b.ics")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, item("c.ics"), testEvent("c", "Third", "20261020T090000Z"))
+
+	got, changed = syncSummaries(t, source)
+	if strings.Join(got, ", ") != "First (moved), Third" {
+		t.Errorf("incremental sync events = %v, want [First (moved) Third]", got)
+	}
+	for _, day := range []int{5, 6, 12, 20} {
+		start := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+		if !overlapsAny(changed, start, start.AddDate(0, 0, 1)) {
+			t.Errorf("changes %v do not cover October %d", changed, day)
+		}
+	}
+	if start := time.Date(2026, 10, 7, 0, 0, 0, 0, time.UTC); overlapsAny(changed, start, start.AddDate(0, 0, 1)) {
+		t.Errorf("changes %v cover the hidden item", changed)
+	}
+
+	// An item caught halfway through being written keeps its previous copy
+	writeFile(t, item("c.ics"), "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n")
+	got, changed = syncSummaries(t, source)
+	if strings.Join(got, ", ") != "First (moved), Third" || len(changed) != 0 {
+		t.Errorf("sync of a partial item events = %v, changes = %v, want [First (moved) Third] and none", got, changed)
+	}
+
+	// and is read again once complete
+	writeFile(t, item("c.ics"), testEvent("c", "Third (moved)", "20261021T090000Z"))
+	got, _ = syncSummaries(t, source)
+	if strings.Join(got, ", ") != "First (moved), Third (moved)" {
+		t.Errorf("sync of the completed item events = %v, want [First (moved) Third (moved)]", got)
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.ics")
+	writeFile(t, path, testEvent("a", "First", "20261005T090000Z"))
+
+	source := newLocalSource(t, config.TypeFile, path)
+
+	got, changed := syncSummaries(t, source)
+	if strings.Join(got, ", ") != "First" {
+		t.Errorf("first sync events = %v, want [First]", got)
+	}
+	if len(changed) != 1 || changed[0] != (DateRange{}) {
+		t.Errorf("first sync changes = %v, want a single unbounded range", changed)
+	}
+
+	// The file is not read again while its modification time and size
+	// are unchanged
+	scramble(t, path)
+	got, changed = syncSummaries(t, source)
+	if strings.Join(got, ", ") != "First" || len(changed) != 0 {
+		t.Errorf("unchanged sync events = %v, changes = %v, want [First] and none", got, changed)
+	}
+
+	writeFile(t, path, testEvent("a", "Second", "20261012T090000Z"))
+	got, changed = syncSummaries(t, source)
+	if strings.Join(got, ", ") != "Second" {
+		t.Errorf("sync of the modified file events = %v, want [Second]", got)
+	}
+	for _, day := range []int{5, 12} {
+		start := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+		if !overlapsAny(changed, start, start.AddDate(0, 0, 1)) {
+			t.Errorf("changes %v do not cover October %d", changed, day)
+		}
+	}
+
+	// A missing file fails the sync rather than emptying the calendar
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := source.Sync(context.Background()); err == nil {
+		t.Error("sync of a missing file succeeded")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/logging"
+)
+
+// captureLogs makes the default logger write JSON lines to the returned
+// buffer until the test ends
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestParseErrorLogging(t *testing.T) {
+	logs := captureLogs(t)
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//mucal//tests//EN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:broken\r\n" +
+		"DTSTAMP:20260901T000000Z\r\n" +
+		"DTSTART:not-a-date\r\n" +
+		"SUMMARY:Broken\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	cal, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := NewSourceBase(&config.Calendar{Name: "work"}, time.UTC)
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	objects := []CalendarObject{{Path: "/calendars/work/broken.ics", Data: cal}}
+	if events := source.ExpandEvents(ctx, objects, time.Time{}, time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)); len(events) != 0 {
+		t.Fatalf("got %d events, want none", len(events))
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log %q: %v", logs.String(), err)
+	}
+	want := map[string]string{
+		"level":      "WARN",
+		"msg":        "failed to parse event",
+		"calendar":   "work",
+		"uid":        "broken",
+		"href":       "/calendars/work/broken.ics",
+		"request_id": "req-1",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %q", key, entry[key], value)
+		}
+	}
+	if entry["error"] == nil {
+		t.Error("error is missing")
+	}
+}
+
+func TestRequestIDForwarding(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(logging.RequestIDHeader))
+	}))
+	defer server.Close()
+
+	for _, client := range []*http.Client{newHTTPClient("user", "secret"), newAnonymousHTTPClient()} {
+		for _, ctx := range []context.Context{
+			logging.WithRequestID(context.Background(), "req-1"),
+			context.Background(),
+		} {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+	}
+
+	want := []string{"req-1", "", "req-1", ""}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("request IDs = %q, want %q", got, want)
+	}
+}
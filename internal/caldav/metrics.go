@@ -0,0 +1,26 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import "github.com/mano/mucal/internal/metrics"
+
+var (
+	eventsParsed = metrics.NewCounterVec("mucal_events_parsed_total",
+		"Events parsed from calendar objects, occurrences of recurring events included.", "calendar")
+	parseErrors = metrics.NewCounterVec("mucal_parse_errors_total",
+		"Calendar objects, events and tasks that failed to parse.", "calendar")
+	recurrencesTruncated = metrics.NewCounterVec("mucal_recurrence_expansions_truncated_total",
+		"Expansions of recurring events truncated at the occurrence limit.", "calendar")
+)
@@ -16,7 +16,8 @@ package caldav
 
 import (
 	"fmt"
-	"os"
+	"log/slog"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -25,32 +26,29 @@ import (
 	"github.com/teambition/rrule-go"
 )
 
-// expandRecurringEvent expands a recurring event based on its RRULE
-func (c *Client) expandRecurringEvent(comp *ical.Component, uid, summary, description, location string,
+// expandRecurringEvent expands a recurring event based on its RRULE, replacing
+// the occurrences matched by the RECURRENCE-ID of the given overrides
+func (c *SourceBase) expandRecurringEvent(log *slog.Logger, comp *ical.Component, overrides []*ical.Component, uid string, details eventDetails,
 	startTime, endTime time.Time, allDay bool, queryStart, queryEnd time.Time) ([]*Event, error) {
 
-	rruleProp := comp.Props.Get("RRULE")
-	if rruleProp == nil {
-		return nil, fmt.Errorf("no RRULE found for recurring event")
+	// Events with only RDATEs get a single-occurrence rule so that DTSTART
+	// remains part of the recurrence set
+	rruleValue := "FREQ=DAILY;COUNT=1"
+	if rruleProp := comp.Props.Get("RRULE"); rruleProp != nil {
+		rruleValue = rruleProp.Value
 	}
 
-	// Parse RRULE
-	// For all-day events, use DATE format; for timed events, use DATETIME format in UTC
-	var dtstart string
-	if allDay {
-		// Use DATE format (YYYYMMDD) for all-day events to avoid timezone shifts
-		dtstart = startTime.Format("20060102")
-	} else {
-		// Use DATETIME format in UTC for timed events
-		dtstart = startTime.UTC().Format("20060102T150405Z")
-	}
-
-	rruleStr := "DTSTART:" + dtstart + "\nRRULE:" + rruleProp.Value
+	// Expand in the time zone of DTSTART, so that occurrences keep their
+	// wall-clock time across DST changes. Floating and all-day events are
+	// expanded in the configured time zone, all-day ones at midnight.
+	dtstart := startTime.In(c.dateTimeLocation(comp.Props.Get("DTSTART"), allDay))
 
-	rOption, err := rrule.StrToROption(rruleStr)
+	// Local UNTIL values are expressed in the same time zone
+	rOption, err := rrule.StrToROptionInLocation("RRULE:"+rruleValue, dtstart.Location())
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse RRULE: %w", err)
 	}
+	rOption.Dtstart = dtstart
 
 	// Create RRule from options
 	rule, err := rrule.NewRRule(*rOption)
@@ -62,19 +60,19 @@ func (c *Client) expandRecurringEvent(comp *ical.Component, uid, summary, descri
 	rset := &rrule.Set{}
 	rset.RRule(rule)
 
-	// Handle EXDATE (excluded dates)
-	// Try to get EXDATE properties
-	exdateProp := comp.Props.Get("EXDATE")
-	if exdateProp != nil {
-		exdates := strings.Split(exdateProp.Value, ",")
-		for _, exdateStr := range exdates {
-			exdate, err := parseICalDate(exdateStr, c.timezone)
-			if err != nil {
-				// Log but continue
-				fmt.Fprintf(os.Stderr, "Failed to parse EXDATE: %v\n", err)
-				continue
-			}
-			rset.ExDate(exdate)
+	// Handle EXDATE (excluded dates) and RDATE (additional dates); both may
+	// appear several times, each with its own TZID and VALUE parameters
+	dtstartLoc := rOption.Dtstart.Location()
+	for _, exdate := range c.parseRecurrenceDates(log, comp, "EXDATE", allDay, dtstartLoc) {
+		rset.ExDate(exdate.start)
+	}
+
+	// RDATEs given as a PERIOD override the event duration
+	periods := make(map[int64]time.Duration)
+	for _, rdate := range c.parseRecurrenceDates(log, comp, "RDATE", allDay, dtstartLoc) {
+		rset.RDate(rdate.start)
+		if rdate.period {
+			periods[rdate.start.Unix()] = rdate.duration
 		}
 	}
 
@@ -89,43 +87,150 @@ func (c *Client) expandRecurringEvent(comp *ical.Component, uid, summary, descri
 	const maxOccurrences = 1000
 	if len(occurrences) > maxOccurrences {
 		occurrences = occurrences[:maxOccurrences]
+		recurrencesTruncated.Inc(c.calendar.Name)
 	}
 
-	// Calculate event duration
+	// Parse overrides, split between single instances and THISANDFUTURE ranges
+	instances := make(map[int64]*eventOverride)
+	var ranges []*eventOverride
+	for _, overrideComp := range overrides {
+		override, err := c.parseOverride(log, overrideComp, dtstartLoc)
+		if err != nil {
+			// Log but continue
+			log.Warn("failed to parse override", "uid", uid, "error", err)
+			parseErrors.Inc(c.calendar.Name)
+			continue
+		}
+		instances[override.recurrenceID.Unix()] = override
+		if override.thisAndFuture {
+			ranges = append(ranges, override)
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].recurrenceID.Before(ranges[j].recurrenceID)
+	})
+
+	// Calculate event duration; all-day events last a number of calendar
+	// days, which may be 23 or 25 hours long
 	duration := endTime.Sub(startTime)
+	days := daysBetween(startTime, endTime)
 
 	var events []*Event
+	matched := make(map[int64]bool)
 
 	// Create event for each occurrence
 	for _, occurrence := range occurrences {
 		// Convert to configured timezone
-		occStart := occurrence.In(c.timezone)
-		occEnd := occStart.Add(duration)
+		origStart := occurrence.In(c.timezone)
+		occStart := origStart
+		occDuration := duration
+		period, hasPeriod := periods[occurrence.Unix()]
+		if hasPeriod {
+			occDuration = period
+		}
+		occEnd := occStart.Add(occDuration)
+		if allDay && !hasPeriod {
+			occEnd = occStart.AddDate(0, 0, days)
+		}
+		occDetails := details
+
+		// THISANDFUTURE overrides shift this and all later occurrences; the
+		// latest one starting at or before this occurrence wins
+		for _, r := range ranges {
+			if occurrence.Before(r.recurrenceID) {
+				break
+			}
+			if allDay {
+				occStart = origStart.AddDate(0, 0, daysBetween(r.recurrenceID, r.start))
+				occEnd = occStart.AddDate(0, 0, daysBetween(r.start, r.end))
+			} else {
+				occStart = origStart.Add(r.start.Sub(r.recurrenceID))
+				occEnd = occStart.Add(r.end.Sub(r.start))
+			}
+			occDetails = r.details
+		}
+
+		// A RECURRENCE-ID matching this occurrence replaces it entirely
+		if override, ok := instances[occurrence.Unix()]; ok {
+			matched[occurrence.Unix()] = true
+			occStart, occEnd = override.start, override.end
+			occDetails = override.details
+		}
 
 		// Filter to only include events that overlap with query range
 		if occEnd.Before(queryStart) || occStart.After(queryEnd) {
 			continue
 		}
 
-		event := &Event{
-			UID:          uid + "_" + occStart.Format("20060102T150405"),
-			Summary:      summary,
-			Description:  description,
-			Location:     location,
-			Start:        occStart,
-			End:          occEnd,
-			AllDay:       allDay,
-			CalendarName: c.calendar.Name,
-			CalendarColor: c.calendar.Color,
-			IsRecurring:  true,
-		}
-
+		event := c.newEvent(uid+"_"+origStart.Format("20060102T150405"), occDetails, occStart, occEnd, allDay, true)
 		events = append(events, event)
 	}
 
+	// Overrides whose original occurrence fell outside the expansion window
+	// may still have been moved into the query range
+	for key, override := range instances {
+		if matched[key] {
+			continue
+		}
+		if override.end.Before(queryStart) || override.start.After(queryEnd) {
+			continue
+		}
+		events = append(events, c.overrideEvent(uid, override))
+	}
+
 	return events, nil
 }
 
+// eventOverride is a VEVENT instance replacing one occurrence of a recurring
+// event, or with RANGE=THISANDFUTURE that occurrence and all later ones
+type eventOverride struct {
+	recurrenceID  time.Time
+	thisAndFuture bool
+	details       eventDetails
+	start         time.Time
+	end           time.Time
+	allDay        bool
+}
+
+// parseOverride parses a VEVENT carrying a RECURRENCE-ID. The recurrence ID
+// is expressed in loc, the location of the recurrence set, so that it can be
+// matched against the generated occurrences.
+func (c *SourceBase) parseOverride(log *slog.Logger, comp *ical.Component, loc *time.Location) (*eventOverride, error) {
+	recurrenceIDProp := comp.Props.Get("RECURRENCE-ID")
+	if recurrenceIDProp == nil {
+		return nil, fmt.Errorf("override missing RECURRENCE-ID")
+	}
+	c.warnUnknownTimezones(log, comp)
+
+	recurrenceID, recurrenceAllDay, err := c.parseDateTime(recurrenceIDProp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RECURRENCE-ID: %w", err)
+	}
+	if recurrenceAllDay {
+		recurrenceID = normalizeDate(recurrenceID, loc)
+	}
+
+	start, end, allDay, err := c.parseEventTimes(comp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventOverride{
+		recurrenceID:  recurrenceID,
+		thisAndFuture: strings.EqualFold(recurrenceIDProp.Params.Get("RANGE"), "THISANDFUTURE"),
+		details:       c.parseEventDetails(comp),
+		start:         start,
+		end:           end,
+		allDay:        allDay,
+	}, nil
+}
+
+// overrideEvent builds the event for an override shown on its own
+func (c *SourceBase) overrideEvent(uid string, override *eventOverride) *Event {
+	uid = uid + "_" + override.recurrenceID.In(c.timezone).Format("20060102T150405")
+	return c.newEvent(uid, override.details, override.start, override.end, override.allDay, true)
+}
+
 // parseDuration parses an iCalendar DURATION value
 // Format: P[n]W[n]D[T[n]H[n]M[n]S]
 func parseDuration(value string) (time.Duration, error) {
@@ -213,21 +318,87 @@ func parseDuration(value string) (time.Duration, error) {
 	return duration, nil
 }
 
-// parseICalDate parses an iCalendar date/datetime string
-func parseICalDate(value string, tz *time.Location) (time.Time, error) {
-	// Try different formats
-	formats := []string{
-		"20060102T150405Z",
-		"20060102T150405",
-		"20060102",
+// recurrenceDate is a single value of an EXDATE or RDATE property
+type recurrenceDate struct {
+	start    time.Time
+	period   bool
+	duration time.Duration
+}
+
+// parseRecurrenceDates parses every value of every property with the given
+// name (EXDATE or RDATE), honouring their TZID and VALUE parameters. Values
+// are expressed like the occurrences of an event with the given all-day flag
+// in the recurrence set location loc, so that they can be compared with them.
+func (c *SourceBase) parseRecurrenceDates(log *slog.Logger, comp *ical.Component, name string, allDay bool, loc *time.Location) []recurrenceDate {
+	var dates []recurrenceDate
+
+	for _, prop := range comp.Props.Values(name) {
+		for _, value := range strings.Split(prop.Value, ",") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+
+			date, err := c.parseRecurrenceDate(&prop, value)
+			if err != nil {
+				// Log but continue
+				log.Warn("failed to parse "+name, "uid", textProp(comp, "UID"), "error", err)
+				continue
+			}
+
+			if allDay {
+				date.start = normalizeDate(date.start, loc)
+			}
+			dates = append(dates, date)
+		}
+	}
+
+	return dates
+}
+
+// parseRecurrenceDate parses one value of a date list property, which is
+// either a DATE, a DATE-TIME or a PERIOD (start/end or start/duration)
+func (c *SourceBase) parseRecurrenceDate(prop *ical.Prop, value string) (recurrenceDate, error) {
+	startValue, endValue, isPeriod := strings.Cut(value, "/")
+
+	start, _, err := c.parseDateTime(&ical.Prop{Name: prop.Name, Params: prop.Params, Value: startValue})
+	if err != nil {
+		return recurrenceDate{}, err
+	}
+
+	date := recurrenceDate{start: start}
+	if !isPeriod {
+		return date, nil
 	}
 
-	for _, format := range formats {
-		t, err := time.ParseInLocation(format, value, tz)
-		if err == nil {
-			return t, nil
+	date.period = true
+	if strings.HasPrefix(endValue, "P") || strings.HasPrefix(endValue, "-P") || strings.HasPrefix(endValue, "+P") {
+		date.duration, err = parseDuration(strings.TrimPrefix(endValue, "+"))
+		if err != nil {
+			return recurrenceDate{}, err
 		}
+		return date, nil
+	}
+
+	end, _, err := c.parseDateTime(&ical.Prop{Name: prop.Name, Params: prop.Params, Value: endValue})
+	if err != nil {
+		return recurrenceDate{}, err
 	}
+	date.duration = end.Sub(start)
+
+	return date, nil
+}
+
+// daysBetween returns the number of calendar days from the date of a to the
+// date of b, ignoring their time of day
+func daysBetween(a, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA) / (24 * time.Hour))
+}
 
-	return time.Time{}, fmt.Errorf("unable to parse date: %s", value)
+// normalizeDate returns midnight of the date of t in loc, which is how
+// occurrences of all-day events are generated
+func normalizeDate(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
 }
@@ -0,0 +1,264 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/config"
+)
+
+// expandOctober expands the given VEVENTs, in UTC, over October 2026,
+// describing each occurrence by its dates or times and summary
+func expandOctober(t *testing.T, vevents ...string) []string {
+	t.Helper()
+	return expandOctoberIn(t, time.UTC, vevents...)
+}
+
+// expandOctoberIn is expandOctober in the given configured time zone
+func expandOctoberIn(t *testing.T, tz *time.Location, vevents ...string) []string {
+	t.Helper()
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//mucal//tests//EN\r\n" +
+		strings.Join(vevents, "") +
+		"END:VCALENDAR\r\n"
+	cal, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := NewSourceBase(&config.Calendar{Name: "test"}, tz)
+	events := source.ExpandEvents(context.Background(), []CalendarObject{{Path: "event.ics", Data: cal}},
+		time.Date(2026, 10, 1, 0, 0, 0, 0, tz), time.Date(2026, 11, 1, 0, 0, 0, 0, tz))
+
+	var got []string
+	for _, event := range events {
+		if event.AllDay {
+			got = append(got, event.Start.Format("2006-01-02")+"/"+event.End.Format("2006-01-02")+" "+event.Summary)
+		} else {
+			got = append(got, event.Start.Format("2006-01-02 15:04")+"-"+event.End.Format("15:04")+" "+event.Summary)
+		}
+	}
+	return got
+}
+
+// vevent returns a VEVENT with the given UID and properties, each given
+// without its line ending
+func vevent(uid string, props ...string) string {
+	return "BEGIN:VEVENT\r\n" +
+		"UID:" + uid + "\r\n" +
+		"DTSTAMP:20260901T000000Z\r\n" +
+		strings.Join(props, "\r\n") + "\r\n" +
+		"END:VEVENT\r\n"
+}
+
+func TestRecurrenceOverrides(t *testing.T) {
+	// Weekly on Mondays, from October 5 to 26
+	weekly := vevent("weekly", "DTSTART:20261005T090000Z", "DTEND:20261005T100000Z",
+		"RRULE:FREQ=WEEKLY;COUNT=4", "SUMMARY:Weekly")
+	weeklyAllDay := vevent("weekly", "DTSTART;VALUE=DATE:20261005", "DTEND;VALUE=DATE:20261006",
+		"RRULE:FREQ=WEEKLY;COUNT=4", "SUMMARY:Weekly")
+	// Weekly on Mondays since January
+	sinceJanuary := vevent("weekly", "DTSTART:20260105T090000Z", "DTEND:20260105T100000Z",
+		"RRULE:FREQ=WEEKLY", "SUMMARY:Weekly")
+
+	tests := []struct {
+		name    string
+		vevents []string
+		want    []string
+	}{
+		{
+			name: "this and future",
+			vevents: []string{weekly, vevent("weekly", "RECURRENCE-ID;RANGE=THISANDFUTURE:20261019T090000Z",
+				"DTSTART:20261019T140000Z", "DTEND:20261019T153000Z", "SUMMARY:Afternoon")},
+			want: []string{
+				"2026-10-05 09:00-10:00 Weekly",
+				"2026-10-12 09:00-10:00 Weekly",
+				"2026-10-19 14:00-15:30 Afternoon",
+				"2026-10-26 14:00-15:30 Afternoon",
+			},
+		},
+		{
+			name: "this and future, all-day",
+			vevents: []string{weeklyAllDay, vevent("weekly", "RECURRENCE-ID;VALUE=DATE;RANGE=THISANDFUTURE:20261019",
+				"DTSTART;VALUE=DATE:20261021", "DTEND;VALUE=DATE:20261023", "SUMMARY:Midweek")},
+			want: []string{
+				"2026-10-05/2026-10-06 Weekly",
+				"2026-10-12/2026-10-13 Weekly",
+				"2026-10-21/2026-10-23 Midweek",
+				"2026-10-28/2026-10-30 Midweek",
+			},
+		},
+		{
+			name: "single override after a range",
+			vevents: []string{weekly,
+				vevent("weekly", "RECURRENCE-ID;RANGE=THISANDFUTURE:20261012T090000Z",
+					"DTSTART:20261012T140000Z", "DTEND:20261012T150000Z", "SUMMARY:Afternoon"),
+				vevent("weekly", "RECURRENCE-ID:20261026T090000Z",
+					"DTSTART:20261027T080000Z", "DTEND:20261027T083000Z", "SUMMARY:Tuesday"),
+			},
+			want: []string{
+				"2026-10-05 09:00-10:00 Weekly",
+				"2026-10-12 14:00-15:00 Afternoon",
+				"2026-10-19 14:00-15:00 Afternoon",
+				"2026-10-27 08:00-08:30 Tuesday",
+			},
+		},
+		{
+			name: "overrides moved from and to far dates",
+			vevents: []string{sinceJanuary,
+				vevent("weekly", "RECURRENCE-ID:20260105T090000Z",
+					"DTSTART:20261014T120000Z", "DTEND:20261014T130000Z", "SUMMARY:From January"),
+				vevent("weekly", "RECURRENCE-ID:20261012T090000Z",
+					"DTSTART:20261214T090000Z", "DTEND:20261214T100000Z", "SUMMARY:To December"),
+			},
+			want: []string{
+				"2026-10-05 09:00-10:00 Weekly",
+				"2026-10-14 12:00-13:00 From January",
+				"2026-10-19 09:00-10:00 Weekly",
+				"2026-10-26 09:00-10:00 Weekly",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandOctober(t, tt.vevents...)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("occurrences =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRecurrenceDates(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Weekly on Mondays, from October 5 to 26
+	weekly := []string{"DTSTART:20261005T090000Z", "DTEND:20261005T100000Z", "RRULE:FREQ=WEEKLY;COUNT=4", "SUMMARY:Weekly"}
+	weeklyRome := []string{"DTSTART;TZID=Europe/Rome:20261005T110000", "DTEND;TZID=Europe/Rome:20261005T120000",
+		"RRULE:FREQ=WEEKLY;COUNT=4", "SUMMARY:Weekly"}
+	weeklyAllDay := []string{"DTSTART;VALUE=DATE:20261005", "DTEND;VALUE=DATE:20261006", "RRULE:FREQ=WEEKLY;COUNT=4", "SUMMARY:Weekly"}
+	// A single day, on Monday October 5
+	once := []string{"DTSTART:20261005T090000Z", "DTEND:20261005T100000Z", "SUMMARY:Once"}
+	onceAllDay := []string{"DTSTART;VALUE=DATE:20261005", "DTEND;VALUE=DATE:20261006", "SUMMARY:Once"}
+
+	tests := []struct {
+		name  string
+		tz    *time.Location
+		props []string
+		want  []string
+	}{
+		{
+			name:  "several EXDATEs",
+			props: append(weekly, "EXDATE:20261012T090000Z", "EXDATE:20261026T090000Z"),
+			want:  []string{"2026-10-05 09:00-10:00 Weekly", "2026-10-19 09:00-10:00 Weekly"},
+		},
+		{
+			name:  "EXDATE list",
+			props: append(weekly, "EXDATE:20261012T090000Z,20261026T090000Z"),
+			want:  []string{"2026-10-05 09:00-10:00 Weekly", "2026-10-19 09:00-10:00 Weekly"},
+		},
+		{
+			// 11:00 in Rome is 09:00 UTC in summer and 10:00 UTC in winter
+			name:  "EXDATE with TZID",
+			props: append(weeklyRome, "EXDATE;TZID=Europe/Rome:20261019T110000"),
+			want: []string{
+				"2026-10-05 09:00-10:00 Weekly",
+				"2026-10-12 09:00-10:00 Weekly",
+				"2026-10-26 10:00-11:00 Weekly",
+			},
+		},
+		{
+			name:  "EXDATE in UTC for a TZID event",
+			props: append(weeklyRome, "EXDATE:20261026T100000Z"),
+			want: []string{
+				"2026-10-05 09:00-10:00 Weekly",
+				"2026-10-12 09:00-10:00 Weekly",
+				"2026-10-19 09:00-10:00 Weekly",
+			},
+		},
+		{
+			name:  "EXDATE dates",
+			props: append(weeklyAllDay, "EXDATE;VALUE=DATE:20261012,20261019"),
+			want:  []string{"2026-10-05/2026-10-06 Weekly", "2026-10-26/2026-10-27 Weekly"},
+		},
+		{
+			name:  "EXDATE dates in another time zone",
+			tz:    rome,
+			props: append(weeklyAllDay, "EXDATE;VALUE=DATE:20261012,20261019"),
+			want:  []string{"2026-10-05/2026-10-06 Weekly", "2026-10-26/2026-10-27 Weekly"},
+		},
+		{
+			name:  "RDATEs",
+			props: append(once, "RDATE:20261015T160000Z,20261020T070000Z", "RDATE;TZID=Europe/Rome:20261028T180000"),
+			want: []string{
+				"2026-10-05 09:00-10:00 Once",
+				"2026-10-15 16:00-17:00 Once",
+				"2026-10-20 07:00-08:00 Once",
+				"2026-10-28 17:00-18:00 Once",
+			},
+		},
+		{
+			name:  "RDATE dates",
+			props: append(onceAllDay, "RDATE;VALUE=DATE:20261014,20261021"),
+			want: []string{
+				"2026-10-05/2026-10-06 Once",
+				"2026-10-14/2026-10-15 Once",
+				"2026-10-21/2026-10-22 Once",
+			},
+		},
+		{
+			name:  "RDATE periods",
+			props: append(once, "RDATE;VALUE=PERIOD:20261021T130000Z/20261021T150000Z,20261022T130000Z/PT30M"),
+			want: []string{
+				"2026-10-05 09:00-10:00 Once",
+				"2026-10-21 13:00-15:00 Once",
+				"2026-10-22 13:00-13:30 Once",
+			},
+		},
+		{
+			name:  "RDATE period of an all-day event",
+			tz:    rome,
+			props: append(onceAllDay, "RDATE;VALUE=PERIOD:20261014T000000/P3D"),
+			want: []string{
+				"2026-10-05/2026-10-06 Once",
+				"2026-10-14/2026-10-17 Once",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tz := tt.tz
+			if tz == nil {
+				tz = time.UTC
+			}
+			got := expandOctoberIn(t, tz, vevent("event", tt.props...))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("occurrences =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mano/mucal/internal/config"
+	"github.com/mano/mucal/internal/logging"
+)
+
+// Source is a calendar backend. It keeps a copy of the calendar as
+// iCalendar objects, which are then parsed into events and tasks.
+//
+// Implementations usually embed a *SourceBase, which provides everything
+// but Sync.
+type Source interface {
+	// GetCalendarName returns the name of the calendar
+	GetCalendarName() string
+
+	// GetCalendar returns the configuration of the calendar
+	GetCalendar() *config.Calendar
+
+	// RefreshInterval returns how often the calendar should be synced, or
+	// zero to use the default interval
+	RefreshInterval() time.Duration
+
+	// Sync brings the copy of the calendar up to date and returns all of
+	// its objects, along with the date ranges touched by the changes since
+	// the previous call. The first call reports a single unbounded range.
+	Sync(ctx context.Context) ([]CalendarObject, []DateRange, error)
+
+	// ExpandEvents parses objects into the events overlapping the given
+	// time range, expanding recurring events. Parse errors are logged
+	// with the request ID of ctx.
+	ExpandEvents(ctx context.Context, objects []CalendarObject, start, end time.Time) []*Event
+
+	// ParseTasks parses every task of the given objects
+	ParseTasks(ctx context.Context, objects []CalendarObject) []*Task
+
+	// ObjectSpan returns the dates covered by the events and tasks of an
+	// object, reporting false if it holds none
+	ObjectSpan(ctx context.Context, obj *CalendarObject) (DateRange, bool)
+}
+
+// Adopter is implemented by sources that can take over the sync state of
+// a previous source of the same calendar, so that a configuration reload
+// does not download it again
+type Adopter interface {
+	// Adopt copies the sync state of old, if it is a source of the same
+	// type. It must be called before the first sync.
+	Adopt(old Source)
+}
+
+// SourceFactory creates the source of a calendar
+type SourceFactory func(cal *config.Calendar, tz *time.Location) (Source, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]SourceFactory{
+		config.TypeCalDAV: func(cal *config.Calendar, tz *time.Location) (Source, error) {
+			return NewClient(cal, tz)
+		},
+		config.TypeICS:  newICSSource,
+		config.TypeFile: newFileSource,
+		config.TypeVdir: newVdirSource,
+	}
+)
+
+// RegisterSource makes a source type available to the "type" field of the
+// calendar configuration. It panics if the type is already registered.
+func RegisterSource(typ string, factory SourceFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("caldav: RegisterSource factory is nil")
+	}
+	if _, dup := factories[typ]; dup {
+		panic("caldav: RegisterSource called twice for type " + typ)
+	}
+	factories[typ] = factory
+}
+
+// NewSource creates the source of a calendar, according to its type
+func NewSource(cal *config.Calendar, tz *time.Location) (Source, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[cal.GetType()]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown calendar type %q", cal.GetType())
+	}
+	return factory(cal, tz)
+}
+
+// SourceBase holds the configuration and sync state of a calendar, and
+// parses its objects. It implements every method of Source but Sync.
+type SourceBase struct {
+	calendar *config.Calendar
+	timezone *time.Location
+	state    syncState
+	zones    zoneCache
+}
+
+// NewSourceBase creates the base of a source for the given calendar, with
+// times converted to the given time zone
+func NewSourceBase(cal *config.Calendar, tz *time.Location) *SourceBase {
+	return &SourceBase{
+		calendar: cal,
+		timezone: tz,
+	}
+}
+
+// GetCalendarName returns the name of the calendar
+func (c *SourceBase) GetCalendarName() string {
+	return c.calendar.Name
+}
+
+// GetCalendar returns the configuration of the calendar
+func (c *SourceBase) GetCalendar() *config.Calendar {
+	return c.calendar
+}
+
+// RefreshInterval returns how often the calendar should be synced, or zero
+// to use the default interval
+func (c *SourceBase) RefreshInterval() time.Duration {
+	return c.calendar.GetRefreshInterval()
+}
+
+// adoptState copies the sync state of another source, along with the
+// validators that adopt copies while both states are locked
+func (c *SourceBase) adoptState(old *SourceBase, adopt func()) {
+	old.state.mu.Lock()
+	defer old.state.mu.Unlock()
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	c.state.loaded = old.state.loaded
+	c.state.ctag = old.state.ctag
+	c.state.syncToken = old.state.syncToken
+	c.state.objects = maps.Clone(old.state.objects)
+	if adopt != nil {
+		adopt()
+	}
+}
+
+// objectLogger returns the logger of the request of ctx, labelled with the
+// calendar and the href of an object
+func (c *SourceBase) objectLogger(ctx context.Context, obj *CalendarObject) *slog.Logger {
+	return logging.FromContext(ctx).With("calendar", c.calendar.Name, "href", obj.Path)
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/teambition/rrule-go"
+)
+
+// DateRange is a range of dates touched by a calendar change. A zero Start
+// or End leaves that side of the range unbounded.
+type DateRange struct {
+	Start time.Time `json:"start,omitzero"`
+	End   time.Time `json:"end,omitzero"`
+}
+
+// Overlaps reports whether the range overlaps [start, end)
+func (r DateRange) Overlaps(start, end time.Time) bool {
+	return (r.Start.IsZero() || r.Start.Before(end)) && (r.End.IsZero() || r.End.After(start))
+}
+
+// ObjectSpan returns the dates covered by the events of a calendar object,
+// from its earliest start (or overridden occurrence) to its latest end.
+// Recurrences without COUNT or UNTIL, and tasks, leave the end unbounded.
+// It reports false for objects holding neither events nor tasks.
+func (c *SourceBase) ObjectSpan(ctx context.Context, obj *CalendarObject) (DateRange, bool) {
+	if obj.Data == nil {
+		return DateRange{}, false
+	}
+	log := c.objectLogger(ctx, obj)
+	c.loadTimezones(log, obj.Data)
+
+	var span DateRange
+	found, unbounded := false, false
+	extend := func(start, end time.Time) {
+		if !found || start.Before(span.Start) {
+			span.Start = start
+		}
+		if !found || end.After(span.End) {
+			span.End = end
+		}
+		found = true
+	}
+
+	for _, comp := range obj.Data.Children {
+		// Open tasks are carried forward until done, so a task change
+		// affects every date from its own onwards
+		if comp.Name == "VTODO" {
+			if task, err := c.parseTask(log, comp); err == nil {
+				date := taskDate(task)
+				if task.Due == nil && task.Start == nil {
+					date = time.Now()
+				}
+				extend(date, date)
+				unbounded = true
+			}
+			continue
+		}
+
+		if comp.Name != "VEVENT" {
+			continue
+		}
+
+		start, end, allDay, err := c.parseEventTimes(comp)
+		if err != nil {
+			continue
+		}
+		duration := end.Sub(start)
+		extend(start, end)
+
+		// An override also frees the occurrence it replaces
+		if recurrenceID := comp.Props.Get("RECURRENCE-ID"); recurrenceID != nil {
+			if t, _, err := c.parseDateTime(recurrenceID); err == nil {
+				extend(t, t.Add(duration))
+			}
+		}
+
+		if comp.Props.Get("RRULE") != nil {
+			dtstart := start.In(c.dateTimeLocation(comp.Props.Get("DTSTART"), allDay))
+			last, ok := lastOccurrence(comp, dtstart)
+			if !ok {
+				unbounded = true
+			} else {
+				extend(start, last.Add(duration))
+			}
+		}
+
+		for _, rdate := range c.parseRecurrenceDates(log, comp, "RDATE", allDay, c.timezone) {
+			rdateDuration := duration
+			if rdate.period {
+				rdateDuration = rdate.duration
+			}
+			extend(rdate.start, rdate.start.Add(rdateDuration))
+		}
+	}
+
+	if unbounded {
+		span.End = time.Time{}
+	}
+
+	return span, found
+}
+
+// lastOccurrence returns the start of the last occurrence of a recurring
+// event, or false if its RRULE has neither COUNT nor UNTIL
+func lastOccurrence(comp *ical.Component, start time.Time) (time.Time, bool) {
+	rOption, err := comp.Props.RecurrenceRule()
+	if err != nil || rOption == nil {
+		return time.Time{}, false
+	}
+
+	if !rOption.Until.IsZero() {
+		return rOption.Until, true
+	}
+	if rOption.Count <= 0 {
+		return time.Time{}, false
+	}
+
+	rOption.Dtstart = start
+	rule, err := rrule.NewRRule(*rOption)
+	if err != nil {
+		return time.Time{}, false
+	}
+	occurrences := rule.All()
+	if len(occurrences) == 0 {
+		return start, true
+	}
+
+	return occurrences[len(occurrences)-1], true
+}
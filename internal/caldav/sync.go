@@ -0,0 +1,339 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/emersion/go-webdav/caldav"
+	"github.com/mano/mucal/internal/logging"
+)
+
+// multigetBatchSize limits the number of hrefs in a calendar-multiget REPORT
+const multigetBatchSize = 100
+
+// syncState tracks what is known about the calendar collection between
+// syncs, so that only changed objects are downloaded
+type syncState struct {
+	mu        sync.Mutex
+	loaded    bool
+	ctag      string
+	syncToken string
+	objects   map[string]CalendarObject
+}
+
+// collectionProps holds the collection properties signalling changes
+type collectionProps struct {
+	ctag      string
+	syncToken string
+}
+
+// Sync brings the local copy of the calendar up to date and returns all of
+// its objects, along with the date ranges touched by the changes since the
+// previous call. The first call reports a single unbounded range.
+//
+// The first call downloads the whole calendar. Later calls skip the download
+// entirely when the collection CTag is unchanged, use an RFC 6578
+// sync-collection REPORT when the server provides sync tokens, and otherwise
+// compare the ETags of every member. Only new and modified objects are then
+// fetched with calendar-multiget, and deleted ones are evicted.
+func (c *Client) Sync(ctx context.Context) ([]CalendarObject, []DateRange, error) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	// Missing CTag or sync token support is not an error
+	props, err := c.fetchCollectionProps(ctx)
+	if err != nil {
+		props = collectionProps{}
+	}
+
+	if !c.state.loaded {
+		if err := c.fullSync(ctx, props); err != nil {
+			return nil, nil, err
+		}
+		return c.state.list(), []DateRange{{}}, nil
+	}
+
+	if props.ctag != "" && props.ctag == c.state.ctag {
+		return c.state.list(), nil, nil
+	}
+
+	var changed []DateRange
+	if c.state.syncToken != "" {
+		changed, err = c.tokenSync(ctx)
+		if err != nil {
+			// The token may have expired; fall back to comparing ETags
+			logging.FromContext(ctx).Warn("sync-collection failed, comparing ETags",
+				"calendar", c.calendar.Name, "error", err)
+			c.state.syncToken = ""
+		}
+	}
+	if c.state.syncToken == "" {
+		changed, err = c.etagSync(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.state.syncToken = props.syncToken
+	}
+
+	c.state.ctag = props.ctag
+
+	return c.state.list(), changed, nil
+}
+
+// Adopt takes over the objects, CTag and sync token of a previous client of
+// the calendar
+func (c *Client) Adopt(old Source) {
+	if o, ok := old.(*Client); ok {
+		c.adoptState(o.SourceBase, nil)
+	}
+}
+
+// fetchCollectionProps reads the CTag and sync token of the collection
+func (c *Client) fetchCollectionProps(ctx context.Context) (collectionProps, error) {
+	ms, err := propfind(ctx, c.httpClient, c.calendar.URL, "0", "<cs:getctag/><d:sync-token/>")
+	if err != nil {
+		return collectionProps{}, err
+	}
+
+	var props collectionProps
+	for _, resp := range ms.Responses {
+		prop := resp.prop()
+		if prop.GetCTag != "" {
+			props.ctag = prop.GetCTag
+		}
+		if prop.SyncToken != "" {
+			props.syncToken = prop.SyncToken
+		}
+	}
+
+	return props, nil
+}
+
+// fullSync downloads every object of the calendar. The collection
+// properties must have been read before, so that changes made during the
+// download are picked up by the next sync.
+func (c *Client) fullSync(ctx context.Context, props collectionProps) error {
+	objects, err := c.FetchObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.state.objects = make(map[string]CalendarObject, len(objects))
+	for _, obj := range objects {
+		c.state.objects[obj.Path] = obj
+	}
+	c.state.ctag = props.ctag
+	c.state.syncToken = props.syncToken
+	c.state.loaded = true
+
+	return nil
+}
+
+// tokenSync applies the changes reported by sync-collection since the
+// stored sync token
+func (c *Client) tokenSync(ctx context.Context) ([]DateRange, error) {
+	ms, err := syncCollection(ctx, c.httpClient, c.calendar.URL, c.state.syncToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var modified, deleted []string
+	for _, resp := range ms.Responses {
+		path, err := resp.path()
+		if err != nil {
+			continue
+		}
+		if resp.notFound() {
+			deleted = append(deleted, path)
+			continue
+		}
+
+		// Skip the collection itself and members whose ETag is unchanged
+		etag := resp.prop().GetETag
+		if etag == "" {
+			continue
+		}
+		if obj, ok := c.state.objects[path]; ok && sameETag(obj.ETag, etag) {
+			continue
+		}
+		modified = append(modified, path)
+	}
+
+	changed, err := c.applyChanges(ctx, modified, deleted)
+	if err != nil {
+		return nil, err
+	}
+	if ms.SyncToken != "" {
+		c.state.syncToken = ms.SyncToken
+	}
+
+	return changed, nil
+}
+
+// etagSync lists the ETag of every member of the collection, fetching the
+// new and modified ones and evicting those no longer listed
+func (c *Client) etagSync(ctx context.Context) ([]DateRange, error) {
+	ms, err := propfind(ctx, c.httpClient, c.calendar.URL, "1", "<d:getetag/>")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar %s: %w", c.calendar.Name, err)
+	}
+
+	listed := make(map[string]bool, len(ms.Responses))
+	var modified, deleted []string
+	for _, resp := range ms.Responses {
+		path, err := resp.path()
+		if err != nil {
+			continue
+		}
+
+		// Skip the collection itself, which has no ETag
+		etag := resp.prop().GetETag
+		if etag == "" {
+			continue
+		}
+
+		listed[path] = true
+		if obj, ok := c.state.objects[path]; ok && sameETag(obj.ETag, etag) {
+			continue
+		}
+		modified = append(modified, path)
+	}
+
+	for path := range c.state.objects {
+		if !listed[path] {
+			deleted = append(deleted, path)
+		}
+	}
+
+	changed, err := c.applyChanges(ctx, modified, deleted)
+	if err != nil {
+		// Nothing else can fetch the changes: the next sync starts over
+		// with a full download
+		c.state.loaded = false
+		return nil, err
+	}
+	return changed, nil
+}
+
+// applyChanges downloads the modified objects and evicts the deleted ones,
+// returning the date ranges covered by their previous and new versions.
+// Nothing is applied unless every download succeeds, so that a failed sync
+// can be retried from the same state.
+func (c *Client) applyChanges(ctx context.Context, modified, deleted []string) ([]DateRange, error) {
+	var fetched []CalendarObject
+	for start := 0; start < len(modified); start += multigetBatchSize {
+		end := min(start+multigetBatchSize, len(modified))
+
+		objects, err := c.caldavClient.MultiGetCalendar(ctx, "", &caldav.CalendarMultiGet{
+			Paths:       modified[start:end],
+			CompRequest: calendarCompRequest(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch changed objects of %s: %w", c.calendar.Name, err)
+		}
+		fetched = append(fetched, objects...)
+	}
+
+	var changed []DateRange
+	addSpan := func(obj CalendarObject) {
+		if span, ok := c.ObjectSpan(ctx, &obj); ok {
+			changed = append(changed, span)
+		}
+	}
+
+	for _, path := range deleted {
+		if obj, ok := c.state.objects[path]; ok {
+			addSpan(obj)
+		}
+		delete(c.state.objects, path)
+	}
+	for _, obj := range fetched {
+		if old, ok := c.state.objects[obj.Path]; ok {
+			addSpan(old)
+		}
+		addSpan(obj)
+		c.state.objects[obj.Path] = obj
+	}
+
+	return changed, nil
+}
+
+// storeObjects stores a complete new set of objects, returning the date
+// ranges touched by the changes, or a single unbounded range on the first
+// call
+func (c *SourceBase) storeObjects(ctx context.Context, objects map[string]CalendarObject) []DateRange {
+	if c.state.loaded {
+		return c.replaceObjects(ctx, objects)
+	}
+
+	c.state.objects = objects
+	c.state.loaded = true
+	return []DateRange{{}}
+}
+
+// replaceObjects replaces the known objects with a complete new set,
+// returning the date ranges covered by the objects that were added, modified
+// or removed. Objects are compared by ETag.
+func (c *SourceBase) replaceObjects(ctx context.Context, objects map[string]CalendarObject) []DateRange {
+	var changed []DateRange
+	addSpan := func(obj CalendarObject) {
+		if span, ok := c.ObjectSpan(ctx, &obj); ok {
+			changed = append(changed, span)
+		}
+	}
+
+	for path, old := range c.state.objects {
+		obj, ok := objects[path]
+		if !ok {
+			addSpan(old)
+		} else if !sameETag(old.ETag, obj.ETag) {
+			addSpan(old)
+			addSpan(obj)
+		}
+	}
+	for path, obj := range objects {
+		if _, ok := c.state.objects[path]; !ok {
+			addSpan(obj)
+		}
+	}
+
+	c.state.objects = objects
+	return changed
+}
+
+// list returns the known objects sorted by path
+func (s *syncState) list() []CalendarObject {
+	objects := make([]CalendarObject, 0, len(s.objects))
+	for _, obj := range s.objects {
+		objects = append(objects, obj)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Path < objects[j].Path
+	})
+	return objects
+}
+
+// sameETag compares two ETags, ignoring quoting and weakness markers
+func sameETag(a, b string) bool {
+	normalize := func(etag string) string {
+		return strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`)
+	}
+	return normalize(a) == normalize(b)
+}
@@ -0,0 +1,222 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mano/mucal/internal/caldavtest"
+	"github.com/mano/mucal/internal/config"
+)
+
+// testEvent returns a calendar holding a single one-hour event
+func testEvent(uid, summary, start string) string {
+	return "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//mucal//tests//EN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:" + uid + "\r\n" +
+		"DTSTAMP:20260901T000000Z\r\n" +
+		"DTSTART:" + start + "\r\n" +
+		"DURATION:PT1H\r\n" +
+		"SUMMARY:" + summary + "\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+}
+
+// newTestClient creates a client for a calendar of the server
+func newTestClient(t *testing.T, server *caldavtest.Server, name string) *Client {
+	t.Helper()
+
+	passwordFile := filepath.Join(t.TempDir(), "password.txt")
+	if err := os.WriteFile(passwordFile, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(&config.Calendar{
+		Name:         name,
+		URL:          server.URL(name),
+		UserID:       "user",
+		PasswordFile: passwordFile,
+		Color:        "#4ECDC4",
+	}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// summaries returns the sorted summaries of the events of the objects
+func summaries(c Source, objects []CalendarObject) []string {
+	var names []string
+	for _, event := range c.ExpandEvents(context.Background(), objects, time.Time{}, time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		names = append(names, event.Summary)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSyncIncremental(t *testing.T) {
+	tests := []struct {
+		name   string
+		noSync bool
+	}{
+		{name: "sync-collection"},
+		{name: "etag", noSync: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := caldavtest.NewServer(t, "")
+			if tt.noSync {
+				server.DisableSync()
+			}
+			mustPut(t, server, "a.ics", testEvent("a", "First", "20261005T090000Z"))
+			mustPut(t, server, "b.ics", testEvent("b", "Second", "20261006T090000Z"))
+
+			client := newTestClient(t, server, "work")
+
+			objects, changed, err := client.Sync(ctx)
+			if err != nil {
+				t.Fatalf("first sync: %v", err)
+			}
+			if got := summaries(client, objects); len(got) != 2 || got[0] != "First" || got[1] != "Second" {
+				t.Errorf("first sync events = %v, want [First Second]", got)
+			}
+			if len(changed) != 1 || changed[0] != (DateRange{}) {
+				t.Errorf("first sync changes = %v, want a single unbounded range", changed)
+			}
+
+			// Nothing changed
+			_, changed, err = client.Sync(ctx)
+			if err != nil {
+				t.Fatalf("unchanged sync: %v", err)
+			}
+			if len(changed) != 0 {
+				t.Errorf("unchanged sync changes = %v, want none", changed)
+			}
+
+			// One object modified, one deleted and one added
+			mustPut(t, server, "a.ics", testEvent("a", "First (moved)", "20261012T090000Z"))
+			server.Delete("work", "b.ics")
+			mustPut(t, server, "c.ics", testEvent("c", "Third", "20261020T090000Z"))
+
+			objects, changed, err = client.Sync(ctx)
+			if err != nil {
+				t.Fatalf("incremental sync: %v", err)
+			}
+			if got := summaries(client, objects); len(got) != 2 || got[0] != "First (moved)" || got[1] != "Third" {
+				t.Errorf("incremental sync events = %v, want [First (moved) Third]", got)
+			}
+
+			// The old and new dates of every change are reported
+			for _, day := range []int{5, 6, 12, 20} {
+				start := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+				if !overlapsAny(changed, start, start.AddDate(0, 0, 1)) {
+					t.Errorf("changes %v do not cover October %d", changed, day)
+				}
+			}
+			for _, day := range []int{1, 25} {
+				start := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+				if overlapsAny(changed, start, start.AddDate(0, 0, 1)) {
+					t.Errorf("changes %v cover unchanged October %d", changed, day)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncMultiGetFailure(t *testing.T) {
+	ctx := context.Background()
+	server := caldavtest.NewServer(t, "")
+	mustPut(t, server, "a.ics", testEvent("a", "First", "20261005T090000Z"))
+	mustPut(t, server, "b.ics", testEvent("b", "Second", "20261006T090000Z"))
+
+	client := newTestClient(t, server, "work")
+	if _, _, err := client.Sync(ctx); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+
+	// The failed sync-collection is retried by comparing ETags, which still
+	// finds the deletion
+	mustPut(t, server, "a.ics", testEvent("a", "First (moved)", "20261012T090000Z"))
+	server.Delete("work", "b.ics")
+	server.FailMultiGet(1)
+
+	objects, changed, err := client.Sync(ctx)
+	if err != nil {
+		t.Fatalf("sync with a failed multiget: %v", err)
+	}
+	if got := summaries(client, objects); len(got) != 1 || got[0] != "First (moved)" {
+		t.Errorf("events = %v, want [First (moved)]", got)
+	}
+	for _, day := range []int{5, 6, 12} {
+		start := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+		if !overlapsAny(changed, start, start.AddDate(0, 0, 1)) {
+			t.Errorf("changes %v do not cover October %d", changed, day)
+		}
+	}
+
+	// The recovered sync needs no full download
+	_, changed, err = client.Sync(ctx)
+	if err != nil {
+		t.Fatalf("unchanged sync: %v", err)
+	}
+	if len(changed) != 0 {
+		t.Errorf("unchanged sync changes = %v, want none", changed)
+	}
+
+	// When both fail, the objects are kept and the next sync downloads
+	// everything
+	mustPut(t, server, "c.ics", testEvent("c", "Third", "20261020T090000Z"))
+	server.FailMultiGet(2)
+	if _, _, err := client.Sync(ctx); err == nil {
+		t.Fatal("sync succeeded with every multiget failing")
+	}
+	objects, changed, err = client.Sync(ctx)
+	if err != nil {
+		t.Fatalf("sync after the failure: %v", err)
+	}
+	if got := summaries(client, objects); len(got) != 2 || got[0] != "First (moved)" || got[1] != "Third" {
+		t.Errorf("events = %v, want [First (moved) Third]", got)
+	}
+	if len(changed) != 1 || changed[0] != (DateRange{}) {
+		t.Errorf("changes = %v, want a single unbounded range", changed)
+	}
+}
+
+// mustPut stores an object in the "work" calendar of the server
+func mustPut(t *testing.T, server *caldavtest.Server, file, data string) {
+	t.Helper()
+	if err := server.Put("work", file, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// overlapsAny reports whether any of the ranges overlaps [start, end)
+func overlapsAny(ranges []DateRange, start, end time.Time) bool {
+	for _, r := range ranges {
+		if r.Overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
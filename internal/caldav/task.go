@@ -0,0 +1,229 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// Task represents a calendar to-do (VTODO)
+type Task struct {
+	UID             string     `json:"uid"`
+	Summary         string     `json:"summary"`
+	Description     string     `json:"description"`
+	Start           *time.Time `json:"start,omitempty"`
+	Due             *time.Time `json:"due,omitempty"`
+	AllDay          bool       `json:"allDay"`
+	Status          string     `json:"status"`
+	Class           string     `json:"class"`
+	PercentComplete int        `json:"percentComplete"`
+	Priority        int        `json:"priority"`
+	Completed       *time.Time `json:"completed,omitempty"`
+	Overdue         bool       `json:"overdue"`
+	CalendarName    string     `json:"calendarName"`
+	CalendarColor   string     `json:"calendarColor"`
+
+	// Used for time-range matching only
+	duration time.Duration
+	created  time.Time
+}
+
+// IsOpen reports whether the task still has to be done
+func (t *Task) IsOpen() bool {
+	switch t.Status {
+	case "COMPLETED", "CANCELLED":
+		return false
+	}
+	return t.Completed == nil && t.PercentComplete < 100
+}
+
+// InRange reports whether the task matches the [start, end) time range,
+// following the VTODO rules of RFC 4791 section 9.9. A due date without a
+// time covers its whole day, as tasks are shown on the day they are due.
+func (t *Task) InRange(start, end time.Time) bool {
+	due := t.Due
+	if due != nil && t.AllDay {
+		endOfDay := due.AddDate(0, 0, 1)
+		due = &endOfDay
+	}
+
+	switch {
+	case t.Start != nil && t.duration != 0:
+		taskEnd := t.Start.Add(t.duration)
+		return !start.After(taskEnd) && (end.After(*t.Start) || !end.Before(taskEnd))
+	case t.Start != nil && due != nil:
+		return (start.Before(*due) || !start.After(*t.Start)) && (end.After(*t.Start) || !end.Before(*due))
+	case t.Start != nil:
+		return !start.After(*t.Start) && end.After(*t.Start)
+	case due != nil:
+		return start.Before(*due) && !end.Before(*due)
+	case t.Completed != nil && !t.created.IsZero():
+		return (!start.After(t.created) || !start.After(*t.Completed)) && (!end.Before(t.created) || !end.Before(*t.Completed))
+	case t.Completed != nil:
+		return !start.After(*t.Completed) && !end.Before(*t.Completed)
+	case !t.created.IsZero():
+		return end.After(t.created)
+	default:
+		return true
+	}
+}
+
+// ParseTasks parses every task of the given calendar objects, sorted by
+// due date. Recurring tasks are reported once, as their first instance.
+func (c *SourceBase) ParseTasks(ctx context.Context, objects []CalendarObject) []*Task {
+	var tasks []*Task
+	for _, obj := range objects {
+		if obj.Data == nil {
+			continue
+		}
+		log := c.objectLogger(ctx, &obj)
+		c.loadTimezones(log, obj.Data)
+
+		for _, comp := range obj.Data.Children {
+			if comp.Name != "VTODO" || comp.Props.Get("RECURRENCE-ID") != nil {
+				continue
+			}
+
+			task, err := c.parseTask(log, comp)
+			if err != nil {
+				// Log error but continue
+				log.Warn("failed to parse task", "uid", textProp(comp, "UID"), "error", err)
+				parseErrors.Inc(c.calendar.Name)
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+	}
+
+	sort.Sort(Tasks(tasks))
+
+	return tasks
+}
+
+// parseTask parses a single VTODO component
+func (c *SourceBase) parseTask(log *slog.Logger, comp *ical.Component) (*Task, error) {
+	uid := comp.Props.Get("UID")
+	if uid == nil {
+		return nil, fmt.Errorf("task missing UID")
+	}
+	c.warnUnknownTimezones(log, comp)
+
+	task := &Task{
+		UID:           uid.Value,
+		Summary:       textProp(comp, "SUMMARY"),
+		Description:   textProp(comp, "DESCRIPTION"),
+		Status:        strings.ToUpper(textProp(comp, "STATUS")),
+		Class:         strings.ToUpper(textProp(comp, "CLASS")),
+		CalendarName:  c.calendar.Name,
+		CalendarColor: c.calendar.Color,
+	}
+
+	if prop := comp.Props.Get("DTSTART"); prop != nil {
+		start, allDay, err := c.parseDateTime(prop)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DTSTART: %w", err)
+		}
+		task.Start = &start
+		task.AllDay = allDay
+	}
+
+	if prop := comp.Props.Get("DUE"); prop != nil {
+		due, allDay, err := c.parseDateTime(prop)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DUE: %w", err)
+		}
+		task.Due = &due
+		task.AllDay = allDay
+	} else if prop := comp.Props.Get("DURATION"); prop != nil && task.Start != nil {
+		duration, err := parseDuration(prop.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DURATION: %w", err)
+		}
+		task.duration = duration
+	}
+
+	if prop := comp.Props.Get("COMPLETED"); prop != nil {
+		completed, _, err := c.parseDateTime(prop)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse COMPLETED: %w", err)
+		}
+		task.Completed = &completed
+	}
+
+	if prop := comp.Props.Get("CREATED"); prop != nil {
+		if created, _, err := c.parseDateTime(prop); err == nil {
+			task.created = created
+		}
+	}
+
+	if prop := comp.Props.Get("PERCENT-COMPLETE"); prop != nil {
+		task.PercentComplete, _ = strconv.Atoi(strings.TrimSpace(prop.Value))
+	}
+	if prop := comp.Props.Get("PRIORITY"); prop != nil {
+		task.Priority, _ = strconv.Atoi(strings.TrimSpace(prop.Value))
+	}
+
+	return task, nil
+}
+
+// taskDate returns the date a task is placed on: its due date, or its start
+// when it has none. Undated tasks sort last.
+func taskDate(t *Task) time.Time {
+	switch {
+	case t.Due != nil:
+		return *t.Due
+	case t.Start != nil:
+		return *t.Start
+	default:
+		return time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Tasks is a slice of Task pointers with sorting capabilities
+type Tasks []*Task
+
+func (t Tasks) Len() int      { return len(t) }
+func (t Tasks) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+
+// Less implements sort.Interface for Tasks
+// Sorted by date, then by priority (1 is highest, 0 is undefined)
+func (t Tasks) Less(i, j int) bool {
+	di, dj := taskDate(t[i]), taskDate(t[j])
+	if !di.Equal(dj) {
+		return di.Before(dj)
+	}
+
+	pi, pj := t[i].Priority, t[j].Priority
+	if pi == 0 {
+		pi = 10
+	}
+	if pj == 0 {
+		pj = 10
+	}
+	if pi != pj {
+		return pi < pj
+	}
+
+	// Finally by summary (alphabetically)
+	return t[i].Summary < t[j].Summary
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/config"
+)
+
+// parseTestTask parses a task with the given properties
+func parseTestTask(t *testing.T, props string) *Task {
+	t.Helper()
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//mucal//tests//EN\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:task\r\n" +
+		"DTSTAMP:20260901T000000Z\r\n" +
+		props +
+		"SUMMARY:Task\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	cal, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := NewSourceBase(&config.Calendar{Name: "test"}, time.UTC)
+	tasks := source.ParseTasks(context.Background(), []CalendarObject{{Path: "task.ics", Data: cal}})
+	if len(tasks) != 1 {
+		t.Fatalf("parsed %d tasks, want 1", len(tasks))
+	}
+	return tasks[0]
+}
+
+func TestTaskInRange(t *testing.T) {
+	// day returns midnight of a day of October 2026
+	day := func(d int) time.Time {
+		return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		props string
+		// want maps the first and last day of ranges to whether they match
+		want map[[2]int]bool
+	}{
+		{
+			name:  "start only",
+			props: "DTSTART:20261010T090000Z\r\n",
+			want:  map[[2]int]bool{{9, 10}: false, {10, 11}: true, {11, 12}: false},
+		},
+		{
+			// The due time itself is included, as the end of the task
+			name:  "due only",
+			props: "DUE:20261010T000000Z\r\n",
+			want:  map[[2]int]bool{{8, 9}: false, {9, 10}: true, {10, 11}: false},
+		},
+		{
+			name:  "due date",
+			props: "DUE;VALUE=DATE:20261010\r\n",
+			want:  map[[2]int]bool{{9, 10}: false, {10, 11}: true, {11, 12}: false},
+		},
+		{
+			name:  "start and duration",
+			props: "DTSTART:20261010T090000Z\r\nDURATION:P2D\r\n",
+			want:  map[[2]int]bool{{9, 10}: false, {10, 11}: true, {12, 13}: true, {13, 14}: false},
+		},
+		{
+			name:  "start and due",
+			props: "DTSTART:20261010T090000Z\r\nDUE:20261012T090000Z\r\n",
+			want:  map[[2]int]bool{{9, 10}: false, {11, 12}: true, {12, 13}: true, {13, 14}: false},
+		},
+		{
+			name:  "start and due dates",
+			props: "DTSTART;VALUE=DATE:20261010\r\nDUE;VALUE=DATE:20261012\r\n",
+			want:  map[[2]int]bool{{9, 10}: false, {10, 11}: true, {12, 13}: true, {13, 14}: false},
+		},
+		{
+			name:  "completed and created",
+			props: "CREATED:20261005T100000Z\r\nCOMPLETED:20261010T120000Z\r\n",
+			want:  map[[2]int]bool{{1, 5}: false, {7, 8}: true, {10, 11}: true, {11, 12}: false},
+		},
+		{
+			name:  "completed only",
+			props: "COMPLETED:20261010T120000Z\r\n",
+			want:  map[[2]int]bool{{7, 8}: false, {10, 11}: true, {11, 12}: false},
+		},
+		{
+			name:  "created only",
+			props: "CREATED:20261005T100000Z\r\n",
+			want:  map[[2]int]bool{{1, 5}: false, {5, 6}: true, {20, 21}: true},
+		},
+		{
+			name: "neither",
+			want: map[[2]int]bool{{1, 2}: true, {20, 21}: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := parseTestTask(t, tt.props)
+			for days, want := range tt.want {
+				if got := task.InRange(day(days[0]), day(days[1])); got != want {
+					t.Errorf("InRange(October %d, October %d) = %v, want %v", days[0], days[1], got, want)
+				}
+			}
+		})
+	}
+}
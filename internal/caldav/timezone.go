@@ -0,0 +1,413 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/teambition/rrule-go"
+)
+
+// zoneHorizon is the date up to which the transitions of a VTIMEZONE are
+// expanded. Later times keep the offset of the last transition.
+var zoneHorizon = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// zoneCache holds the time zones resolved for the TZIDs of a calendar,
+// including those defined by the VTIMEZONE components of its objects
+type zoneCache struct {
+	mu       sync.Mutex
+	resolved map[string]*time.Location
+	defined  map[string]*time.Location
+	warned   map[string]bool
+}
+
+// resolveTZID returns the time zone named by a TZID parameter, trying in
+// order the IANA database, the Windows zone names used by Outlook and
+// Exchange, globally unique "/vendor/.../Area/City" names, and the VTIMEZONE
+// definitions found in the calendar. It reports false for unknown zones.
+func (c *SourceBase) resolveTZID(tzid string) (*time.Location, bool) {
+	c.zones.mu.Lock()
+	defer c.zones.mu.Unlock()
+
+	if loc, ok := c.zones.resolved[tzid]; ok {
+		return loc, true
+	}
+
+	loc := lookupTZID(tzid)
+	if loc == nil {
+		// Definitions are not cached with the others, as an object
+		// may later provide a better match
+		if loc, ok := c.zones.defined[tzid]; ok {
+			return loc, true
+		}
+		return nil, false
+	}
+
+	if c.zones.resolved == nil {
+		c.zones.resolved = make(map[string]*time.Location)
+	}
+	c.zones.resolved[tzid] = loc
+	return loc, true
+}
+
+// lookupTZID returns the time zone of a TZID known without any VTIMEZONE,
+// or nil
+func lookupTZID(tzid string) *time.Location {
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc
+	}
+
+	if name, ok := windowsZones[tzid]; ok {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+
+	// Globally unique TZIDs, like "/mozilla.org/20050126_1/Europe/Rome",
+	// usually end with an IANA name
+	if strings.HasPrefix(tzid, "/") {
+		parts := strings.Split(tzid, "/")
+		for i := 1; i < len(parts); i++ {
+			if loc, err := time.LoadLocation(strings.Join(parts[i:], "/")); err == nil {
+				return loc
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadTimezones registers the time zones defined by the VTIMEZONE components
+// of a calendar object, unless their TZID already resolves. The first
+// definition of a TZID wins.
+func (c *SourceBase) loadTimezones(log *slog.Logger, cal *ical.Calendar) {
+	if cal == nil {
+		return
+	}
+
+	for _, comp := range cal.Children {
+		if comp.Name != "VTIMEZONE" {
+			continue
+		}
+
+		tzidProp := comp.Props.Get("TZID")
+		if tzidProp == nil || tzidProp.Value == "" {
+			continue
+		}
+		tzid := tzidProp.Value
+		if _, ok := c.resolveTZID(tzid); ok {
+			continue
+		}
+
+		loc, err := locationFromVTimezone(tzid, comp)
+		if err != nil {
+			log.Warn("failed to parse VTIMEZONE", "tzid", tzid, "error", err)
+			continue
+		}
+
+		c.zones.mu.Lock()
+		if c.zones.defined == nil {
+			c.zones.defined = make(map[string]*time.Location)
+		}
+		if _, ok := c.zones.defined[tzid]; !ok {
+			c.zones.defined[tzid] = loc
+		}
+		c.zones.mu.Unlock()
+	}
+}
+
+// warnUnknownTimezones logs the TZIDs of an event or task that resolve to no
+// time zone, once per component and TZID. Their times are read in the
+// configured time zone instead.
+func (c *SourceBase) warnUnknownTimezones(log *slog.Logger, comp *ical.Component) {
+	for _, name := range []string{"DTSTART", "DTEND", "DUE", "RECURRENCE-ID", "EXDATE", "RDATE"} {
+		for _, prop := range comp.Props.Values(name) {
+			tzid := prop.Params.Get("TZID")
+			if tzid == "" {
+				continue
+			}
+			if _, ok := c.resolveTZID(tzid); ok {
+				continue
+			}
+
+			uid := textProp(comp, "UID")
+			key := uid + "\x00" + tzid
+
+			c.zones.mu.Lock()
+			warned := c.zones.warned[key]
+			if !warned {
+				if c.zones.warned == nil {
+					c.zones.warned = make(map[string]bool)
+				}
+				c.zones.warned[key] = true
+			}
+			c.zones.mu.Unlock()
+
+			if !warned {
+				log.Warn("unknown time zone", "tzid", tzid, "uid", uid,
+					"summary", textProp(comp, "SUMMARY"), "fallback", c.timezone.String())
+			}
+		}
+	}
+}
+
+// zoneTransition is a change of UTC offset within a VTIMEZONE
+type zoneTransition struct {
+	at     int64
+	from   int
+	offset int
+	isDST  bool
+	name   string
+}
+
+// locationFromVTimezone builds a time zone from the STANDARD and DAYLIGHT
+// observances of a VTIMEZONE component
+func locationFromVTimezone(tzid string, comp *ical.Component) (*time.Location, error) {
+	var transitions []zoneTransition
+	for _, observance := range comp.Children {
+		if observance.Name != "STANDARD" && observance.Name != "DAYLIGHT" {
+			continue
+		}
+
+		observed, err := observanceTransitions(observance)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", observance.Name, err)
+		}
+		transitions = append(transitions, observed...)
+	}
+	if len(transitions) == 0 {
+		return nil, fmt.Errorf("no observances")
+	}
+
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].at < transitions[j].at
+	})
+
+	// Zones with a single onset have a fixed offset
+	if len(transitions) == 1 {
+		return time.FixedZone(transitions[0].name, transitions[0].offset), nil
+	}
+
+	return time.LoadLocationFromTZData(tzid, encodeTZif(transitions))
+}
+
+// observanceTransitions returns the transitions of a STANDARD or DAYLIGHT
+// observance, from its DTSTART, RRULE and RDATEs up to zoneHorizon
+func observanceTransitions(observance *ical.Component) ([]zoneTransition, error) {
+	from, err := parseUTCOffset(observance.Props.Get("TZOFFSETFROM"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TZOFFSETFROM: %w", err)
+	}
+	to, err := parseUTCOffset(observance.Props.Get("TZOFFSETTO"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TZOFFSETTO: %w", err)
+	}
+
+	dtstartProp := observance.Props.Get("DTSTART")
+	if dtstartProp == nil {
+		return nil, fmt.Errorf("missing DTSTART")
+	}
+
+	// Onsets are local times, handled here as if they were UTC
+	dtstart, err := time.ParseInLocation("20060102T150405", dtstartProp.Value, time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DTSTART: %w", err)
+	}
+	onsets := []time.Time{dtstart}
+
+	if rruleProp := observance.Props.Get("RRULE"); rruleProp != nil {
+		rOption, err := rrule.StrToROptionInLocation("RRULE:"+rruleProp.Value, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE: %w", err)
+		}
+		rOption.Dtstart = dtstart
+		// UNTIL is a UTC time, unlike the onsets
+		if !rOption.Until.IsZero() {
+			rOption.Until = rOption.Until.Add(time.Duration(from) * time.Second)
+		}
+
+		rule, err := rrule.NewRRule(*rOption)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE: %w", err)
+		}
+		onsets = append(onsets, rule.Between(dtstart, zoneHorizon, true)...)
+	}
+
+	for _, prop := range observance.Props.Values("RDATE") {
+		for _, value := range strings.Split(prop.Value, ",") {
+			onset, err := time.ParseInLocation("20060102T150405", strings.TrimSpace(value), time.UTC)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RDATE: %w", err)
+			}
+			onsets = append(onsets, onset)
+		}
+	}
+
+	name := textProp(observance, "TZNAME")
+	if name == "" {
+		name = offsetName(to)
+	}
+	isDST := observance.Name == "DAYLIGHT"
+
+	transitions := make([]zoneTransition, 0, len(onsets))
+	for _, onset := range onsets {
+		transitions = append(transitions, zoneTransition{
+			at:     onset.Add(-time.Duration(from) * time.Second).Unix(),
+			from:   from,
+			offset: to,
+			isDST:  isDST,
+			name:   name,
+		})
+	}
+
+	return transitions, nil
+}
+
+// parseUTCOffset parses a UTC-OFFSET value (+HHMM or +HHMMSS) into seconds
+func parseUTCOffset(prop *ical.Prop) (int, error) {
+	if prop == nil {
+		return 0, fmt.Errorf("missing value")
+	}
+
+	value := strings.TrimSpace(prop.Value)
+	if len(value) != 5 && len(value) != 7 || (value[0] != '+' && value[0] != '-') {
+		return 0, fmt.Errorf("invalid offset %q", value)
+	}
+
+	var offset int
+	for i, unit := range []int{3600, 60, 1} {
+		if 1+2*i >= len(value) {
+			break
+		}
+		n, err := strconv.Atoi(value[1+2*i : 3+2*i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset %q", value)
+		}
+		offset += n * unit
+	}
+
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// offsetName returns the abbreviation of a zone without TZNAME, in the
+// style of the IANA database ("+01", "-0330")
+func offsetName(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	hours, minutes := offset/3600, offset%3600/60
+	if minutes != 0 {
+		return fmt.Sprintf("%c%02d%02d", sign, hours, minutes)
+	}
+	return fmt.Sprintf("%c%02d", sign, hours)
+}
+
+// encodeTZif encodes sorted transitions in the TZif version 2 format read by
+// time.LoadLocationFromTZData. Times before the first transition have the
+// offset it starts from.
+func encodeTZif(transitions []zoneTransition) []byte {
+	type zoneType struct {
+		offset int
+		isDST  bool
+		name   string
+	}
+
+	// The first zone type is the one in effect before any transition; it
+	// is kept apart from the others so that Go picks it for earlier times
+	initial := zoneType{offset: transitions[0].from, name: offsetName(transitions[0].from)}
+	for _, tr := range transitions {
+		if tr.offset == initial.offset {
+			initial = zoneType{offset: tr.offset, isDST: tr.isDST, name: tr.name}
+			break
+		}
+	}
+	types := []zoneType{initial}
+
+	var abbrevs []byte
+	abbrevIndex := make(map[string]int)
+	indexOf := func(name string) int {
+		if i, ok := abbrevIndex[name]; ok {
+			return i
+		}
+		abbrevIndex[name] = len(abbrevs)
+		abbrevs = append(append(abbrevs, name...), 0)
+		return abbrevIndex[name]
+	}
+	indexOf(initial.name)
+
+	typeIndex := make(map[zoneType]int)
+	var times []int64
+	var indices []byte
+	for i, tr := range transitions {
+		// Skip duplicated onsets, as DTSTART usually matches the RRULE
+		if i > 0 && tr.at == transitions[i-1].at {
+			continue
+		}
+
+		zt := zoneType{offset: tr.offset, isDST: tr.isDST, name: tr.name}
+		index, ok := typeIndex[zt]
+		if !ok {
+			index = len(types)
+			typeIndex[zt] = index
+			types = append(types, zt)
+			indexOf(zt.name)
+		}
+		times = append(times, tr.at)
+		indices = append(indices, byte(index))
+	}
+
+	var buf bytes.Buffer
+	header := func(timecnt, typecnt, charcnt int) {
+		buf.WriteString("TZif2")
+		buf.Write(make([]byte, 15))
+		// isutcnt, isstdcnt, leapcnt, timecnt, typecnt, charcnt
+		for _, n := range []int{0, 0, 0, timecnt, typecnt, charcnt} {
+			binary.Write(&buf, binary.BigEndian, uint32(n))
+		}
+	}
+
+	// An empty version 1 block, followed by the 64-bit data
+	header(0, 0, 0)
+	header(len(times), len(types), len(abbrevs))
+	for _, t := range times {
+		binary.Write(&buf, binary.BigEndian, t)
+	}
+	buf.Write(indices)
+	for _, zt := range types {
+		binary.Write(&buf, binary.BigEndian, int32(zt.offset))
+		isDST := byte(0)
+		if zt.isDST {
+			isDST = 1
+		}
+		buf.WriteByte(isDST)
+		buf.WriteByte(byte(indexOf(zt.name)))
+	}
+	buf.Write(abbrevs)
+
+	return buf.Bytes()
+}
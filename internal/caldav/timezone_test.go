@@ -0,0 +1,185 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/mano/mucal/internal/config"
+)
+
+// outlookTimezone is a VTIMEZONE as written by Outlook, whose observances
+// start in 1601
+const outlookTimezone = "BEGIN:VTIMEZONE\r\n" +
+	"TZID:W. Europe Standard Time\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:16010101T030000\r\n" +
+	"TZOFFSETFROM:+0200\r\n" +
+	"TZOFFSETTO:+0100\r\n" +
+	"RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=-1SU;BYMONTH=10\r\n" +
+	"END:STANDARD\r\n" +
+	"BEGIN:DAYLIGHT\r\n" +
+	"DTSTART:16010101T020000\r\n" +
+	"TZOFFSETFROM:+0100\r\n" +
+	"TZOFFSETTO:+0200\r\n" +
+	"RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=-1SU;BYMONTH=3\r\n" +
+	"END:DAYLIGHT\r\n" +
+	"END:VTIMEZONE\r\n"
+
+// customTimezone defines the rules of Central European Time under a name
+// unknown to the IANA database
+const customTimezone = "BEGIN:VTIMEZONE\r\n" +
+	"TZID:Office Time\r\n" +
+	"BEGIN:DAYLIGHT\r\n" +
+	"DTSTART:19810329T020000\r\n" +
+	"TZOFFSETFROM:+0100\r\n" +
+	"TZOFFSETTO:+0200\r\n" +
+	"TZNAME:CEST\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\n" +
+	"END:DAYLIGHT\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:19961027T030000\r\n" +
+	"TZOFFSETFROM:+0200\r\n" +
+	"TZOFFSETTO:+0100\r\n" +
+	"TZNAME:CET\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n"
+
+// weeklyEvent returns a calendar object holding a weekly event starting on
+// Monday October 19, 2026 at 09:00 in the given time zone
+func weeklyEvent(t *testing.T, tzid, vtimezone string) CalendarObject {
+	t.Helper()
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//mucal//tests//EN\r\n" +
+		vtimezone +
+		"BEGIN:VEVENT\r\n" +
+		"UID:weekly\r\n" +
+		"DTSTAMP:20260901T000000Z\r\n" +
+		"DTSTART;TZID=\"" + tzid + "\":20261019T090000\r\n" +
+		"DTEND;TZID=\"" + tzid + "\":20261019T100000\r\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=2\r\n" +
+		"SUMMARY:Weekly\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return CalendarObject{Path: "weekly.ics", Data: cal}
+}
+
+func TestTimezoneResolution(t *testing.T) {
+	tests := []struct {
+		name      string
+		tzid      string
+		vtimezone string
+		want      []string
+	}{
+		{
+			name: "iana",
+			tzid: "Europe/Rome",
+			want: []string{"2026-10-19T07:00:00Z", "2026-10-26T08:00:00Z"},
+		},
+		{
+			name:      "windows",
+			tzid:      "W. Europe Standard Time",
+			vtimezone: outlookTimezone,
+			want:      []string{"2026-10-19T07:00:00Z", "2026-10-26T08:00:00Z"},
+		},
+		{
+			name: "windows without definition",
+			tzid: "Eastern Standard Time",
+			want: []string{"2026-10-19T13:00:00Z", "2026-10-26T13:00:00Z"},
+		},
+		{
+			name: "globally unique",
+			tzid: "/mozilla.org/20050126_1/Europe/Rome",
+			want: []string{"2026-10-19T07:00:00Z", "2026-10-26T08:00:00Z"},
+		},
+		{
+			name:      "vtimezone",
+			tzid:      "Office Time",
+			vtimezone: customTimezone,
+			want:      []string{"2026-10-19T07:00:00Z", "2026-10-26T08:00:00Z"},
+		},
+		{
+			// Unknown zones fall back to the configured one
+			name: "unknown",
+			tzid: "Office Time",
+			want: []string{"2026-10-19T09:00:00Z", "2026-10-26T09:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewSourceBase(&config.Calendar{Name: "test"}, time.UTC)
+			objects := []CalendarObject{weeklyEvent(t, tt.tzid, tt.vtimezone)}
+
+			events := source.ExpandEvents(context.Background(), objects,
+				time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC))
+
+			var got []string
+			for _, event := range events {
+				got = append(got, event.Start.UTC().Format(time.RFC3339))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("starts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocationFromVTimezone(t *testing.T) {
+	cal, err := ical.NewDecoder(strings.NewReader("BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//mucal//tests//EN\r\n" +
+		customTimezone +
+		"END:VCALENDAR\r\n")).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc, err := locationFromVTimezone("Office Time", cal.Children[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every hour around the transitions of 2026 matches the IANA zone
+	for _, day := range []time.Time{
+		time.Date(2026, 3, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC),
+	} {
+		for hour := 0; hour < 72; hour++ {
+			instant := day.Add(time.Duration(hour) * time.Hour)
+			gotName, gotOffset := instant.In(loc).Zone()
+			wantName, wantOffset := instant.In(rome).Zone()
+			if gotName != wantName || gotOffset != wantOffset {
+				t.Errorf("zone at %s = %s %d, want %s %d", instant, gotName, gotOffset, wantName, wantOffset)
+			}
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// ruleCheckYears is how many years the changes of a time zone must follow
+// yearly rules before these are written as RRULEs
+const ruleCheckYears = 10
+
+// zoneChange is a change of the UTC offset or daylight saving time of a
+// time zone
+type zoneChange struct {
+	at    time.Time
+	from  int
+	to    int
+	isDST bool
+	name  string
+}
+
+// onset returns the local time of the change, before it applies
+func (c zoneChange) onset() time.Time {
+	return c.at.In(time.FixedZone("", c.from))
+}
+
+// generateTimezone describes a time zone as a VTIMEZONE with the given
+// TZID, from the start of the year of since onwards. When the changes of
+// the coming year follow yearly rules for ruleCheckYears more years, they
+// are written as RRULEs; otherwise the changes of zones that still change
+// are listed up to zoneHorizon.
+func generateTimezone(tzid string, loc *time.Location, since time.Time) *ical.Component {
+	now := time.Now()
+	if since.IsZero() || since.After(now) {
+		since = now
+	}
+	start := time.Date(since.In(loc).Year(), 1, 1, 0, 0, 0, 0, loc)
+	ruleYear := now.Year() + 1
+	ruleStart := time.Date(ruleYear, 1, 1, 0, 0, 0, 0, loc)
+	ruleEnd := ruleStart.AddDate(1, 0, 0)
+
+	changes := zoneChanges(loc, start, ruleStart)
+	ruled := zoneChanges(loc, ruleStart, ruleEnd)
+	rules, ok := zoneRules(loc, ruled, ruleEnd)
+	if !ok {
+		// Zones changing irregularly are listed up to the horizon, while
+		// those that stopped changing keep their last offset
+		changes = append(changes, ruled...)
+		if len(ruled) > 0 {
+			changes = append(changes, zoneChanges(loc, ruleEnd, zoneHorizon)...)
+		}
+		ruled = nil
+	}
+
+	tz := ical.NewComponent(ical.CompTimezone)
+	tz.Props.SetText(ical.PropTimezoneID, tzid)
+
+	// The zone as of the start covers the times before the first change
+	name, offset := start.Zone()
+	tz.Children = append(tz.Children, observance(zoneChange{
+		at:    start,
+		from:  offset,
+		to:    offset,
+		isDST: start.IsDST(),
+		name:  name,
+	}))
+
+	// Changes to the same offset share an observance, listing the others
+	// as RDATEs
+	observances := make(map[zoneChange]*ical.Component)
+	for _, change := range changes {
+		key := change
+		key.at = time.Time{}
+		if comp, ok := observances[key]; ok {
+			rdate := comp.Props.Get(ical.PropRecurrenceDates)
+			if rdate == nil {
+				rdate = ical.NewProp(ical.PropRecurrenceDates)
+				rdate.Value = change.onset().Format("20060102T150405")
+				comp.Props.Set(rdate)
+			} else {
+				rdate.Value += "," + change.onset().Format("20060102T150405")
+			}
+			continue
+		}
+		observances[key] = observance(change)
+		tz.Children = append(tz.Children, observances[key])
+	}
+
+	for i, change := range ruled {
+		comp := observance(change)
+		rrule := ical.NewProp(ical.PropRecurrenceRule)
+		rrule.Value = rules[i]
+		comp.Props.Set(rrule)
+		tz.Children = append(tz.Children, comp)
+	}
+
+	return tz
+}
+
+// observance returns the STANDARD or DAYLIGHT observance starting with a
+// change
+func observance(c zoneChange) *ical.Component {
+	name := ical.CompTimezoneStandard
+	if c.isDST {
+		name = ical.CompTimezoneDaylight
+	}
+	comp := ical.NewComponent(name)
+
+	// Onsets are local times and offsets have their own value type, neither
+	// of which go-ical sets
+	for prop, value := range map[string]string{
+		ical.PropDateTimeStart:      c.onset().Format("20060102T150405"),
+		ical.PropTimezoneOffsetFrom: formatUTCOffset(c.from),
+		ical.PropTimezoneOffsetTo:   formatUTCOffset(c.to),
+	} {
+		p := ical.NewProp(prop)
+		p.Value = value
+		comp.Props.Set(p)
+	}
+	comp.Props.SetText(ical.PropTimezoneName, c.name)
+
+	return comp
+}
+
+// zoneChanges returns the changes of a time zone within [start, end). Days
+// are checked one by one, so only the last of several changes on the same
+// day is found.
+func zoneChanges(loc *time.Location, start, end time.Time) []zoneChange {
+	state := func(unix int64) (int, bool) {
+		t := time.Unix(unix, 0).In(loc)
+		_, offset := t.Zone()
+		return offset, t.IsDST()
+	}
+
+	var changes []zoneChange
+	day := int64(24 * 60 * 60)
+	for lo, last := start.Unix(), end.Unix(); lo < last; lo += day {
+		hi := min(lo+day, last)
+		fromOffset, fromDST := state(lo)
+		if toOffset, toDST := state(hi); toOffset == fromOffset && toDST == fromDST {
+			continue
+		}
+
+		// Find the second of the change
+		for a, b := lo, hi; ; {
+			if b-a <= 1 {
+				at := time.Unix(b, 0).In(loc)
+				name, offset := at.Zone()
+				changes = append(changes, zoneChange{at: at, from: fromOffset, to: offset, isDST: at.IsDST(), name: name})
+				break
+			}
+			mid := a + (b-a)/2
+			if offset, isDST := state(mid); offset == fromOffset && isDST == fromDST {
+				a = mid
+			} else {
+				b = mid
+			}
+		}
+	}
+
+	return changes
+}
+
+// zoneRules returns the yearly RRULEs of the changes of a time zone during
+// a year, such as the last Sunday of March at 02:00, provided that the
+// ruleCheckYears years from next follow them and have no other changes. It
+// reports false for zones whose changes follow no such rules, and for zones
+// without changes.
+func zoneRules(loc *time.Location, changes []zoneChange, next time.Time) ([]string, bool) {
+	if len(changes) == 0 {
+		return nil, false
+	}
+
+	later := zoneChanges(loc, next, next.AddDate(ruleCheckYears, 0, 0))
+	if len(later) != len(changes)*ruleCheckYears {
+		return nil, false
+	}
+
+	rules := make([]string, len(changes))
+	for i, change := range changes {
+		onset := change.onset()
+		days := time.Date(onset.Year(), onset.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+		// The last weekday of the month is preferred to the fourth
+		var candidates []int
+		if onset.Day()+7 > days {
+			candidates = append(candidates, -1)
+		}
+		if onset.Day() <= 28 {
+			candidates = append(candidates, (onset.Day()-1)/7+1)
+		}
+
+		for _, n := range candidates {
+			if followsRule(loc, change, n) {
+				rules[i] = fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", onset.Month(), n,
+					strings.ToUpper(onset.Weekday().String()[:2]))
+				break
+			}
+		}
+		if rules[i] == "" {
+			return nil, false
+		}
+	}
+
+	return rules, true
+}
+
+// followsRule reports whether a time zone changes as it did with a change
+// on the nth weekday of the same month (the last one if -1), at the same
+// local time, for ruleCheckYears years
+func followsRule(loc *time.Location, change zoneChange, n int) bool {
+	onset := change.onset()
+	for year := onset.Year() + 1; year <= onset.Year()+ruleCheckYears; year++ {
+		var day int
+		if n > 0 {
+			first := time.Date(year, onset.Month(), 1, 0, 0, 0, 0, time.UTC).Weekday()
+			day = 1 + int(onset.Weekday()-first+7)%7 + 7*(n-1)
+		} else {
+			last := time.Date(year, onset.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+			day = last.Day() - int(last.Weekday()-onset.Weekday()+7)%7
+		}
+
+		at := time.Date(year, onset.Month(), day, onset.Hour(), onset.Minute(), onset.Second(), 0, onset.Location())
+		_, before := at.Add(-time.Second).In(loc).Zone()
+		_, after := at.In(loc).Zone()
+		if before != change.from || after != change.to || at.In(loc).IsDST() != change.isDST {
+			return false
+		}
+	}
+	return true
+}
+
+// formatUTCOffset formats an offset in seconds as a UTC-OFFSET value
+func formatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	hours, minutes, seconds := offset/3600, offset%3600/60, offset%60
+	if seconds != 0 {
+		return fmt.Sprintf("%c%02d%02d%02d", sign, hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, hours, minutes)
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The go-webdav client does not expose every property and report μCal
+// needs, so the few missing requests are issued directly.
+
+// multistatus is a WebDAV multi-status response body
+type multistatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"DAV: response"`
+	SyncToken string        `xml:"DAV: sync-token"`
+}
+
+// davResponse is a single response within a multi-status body
+type davResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Status    string        `xml:"DAV: status"`
+	Propstats []davPropstat `xml:"DAV: propstat"`
+}
+
+// davPropstat groups properties sharing the same status
+type davPropstat struct {
+	Prop   davProp `xml:"DAV: prop"`
+	Status string  `xml:"DAV: status"`
+}
+
+// davProp holds the properties μCal reads from PROPFIND responses
+type davProp struct {
+	CalendarColor string `xml:"http://apple.com/ns/ical/ calendar-color"`
+	GetCTag       string `xml:"http://calendarserver.org/ns/ getctag"`
+	SyncToken     string `xml:"DAV: sync-token"`
+	GetETag       string `xml:"DAV: getetag"`
+}
+
+// prop returns the properties of a response that were found
+func (r *davResponse) prop() davProp {
+	var found davProp
+	for _, ps := range r.Propstats {
+		if ps.Status != "" && !strings.Contains(ps.Status, " 200 ") {
+			continue
+		}
+		if ps.Prop.CalendarColor != "" {
+			found.CalendarColor = ps.Prop.CalendarColor
+		}
+		if ps.Prop.GetCTag != "" {
+			found.GetCTag = ps.Prop.GetCTag
+		}
+		if ps.Prop.SyncToken != "" {
+			found.SyncToken = ps.Prop.SyncToken
+		}
+		if ps.Prop.GetETag != "" {
+			found.GetETag = ps.Prop.GetETag
+		}
+	}
+	return found
+}
+
+// notFound reports whether the response reports a missing resource, as
+// sync-collection does for deleted members
+func (r *davResponse) notFound() bool {
+	return strings.Contains(r.Status, " 404 ")
+}
+
+// path returns the unescaped path of the response href
+func (r *davResponse) path() (string, error) {
+	u, err := url.Parse(strings.TrimSpace(r.Href))
+	if err != nil {
+		return "", fmt.Errorf("invalid href %q: %w", r.Href, err)
+	}
+	return u.Path, nil
+}
+
+// propfind issues a PROPFIND request for the given properties and returns
+// the decoded multi-status response
+func propfind(ctx context.Context, httpClient *http.Client, target, depth, props string) (*multistatus, error) {
+	body := xml.Header +
+		`<d:propfind xmlns:d="DAV:" xmlns:a="http://apple.com/ns/ical/" xmlns:cs="http://calendarserver.org/ns/">` +
+		`<d:prop>` + props + `</d:prop></d:propfind>`
+	return doMultistatus(ctx, httpClient, "PROPFIND", target, depth, body)
+}
+
+// syncCollection issues an RFC 6578 sync-collection REPORT listing the
+// members changed since the given token along with their ETags
+func syncCollection(ctx context.Context, httpClient *http.Client, target, token string) (*multistatus, error) {
+	var escaped strings.Builder
+	if err := xml.EscapeText(&escaped, []byte(token)); err != nil {
+		return nil, err
+	}
+
+	body := xml.Header +
+		`<d:sync-collection xmlns:d="DAV:">` +
+		`<d:sync-token>` + escaped.String() + `</d:sync-token>` +
+		`<d:sync-level>1</d:sync-level>` +
+		`<d:prop><d:getetag/></d:prop></d:sync-collection>`
+	return doMultistatus(ctx, httpClient, "REPORT", target, "", body)
+}
+
+// doMultistatus sends an XML request expecting a 207 Multi-Status response
+func doMultistatus(ctx context.Context, httpClient *http.Client, method, target, depth, body string) (*multistatus, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	if depth != "" {
+		req.Header.Set("Depth", depth)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("%s %s: %w", method, target, &StatusError{Code: resp.StatusCode, Status: resp.Status})
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("%s %s: failed to decode response: %w", method, target, &parseError{err})
+	}
+
+	return &ms, nil
+}
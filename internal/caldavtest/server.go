@@ -0,0 +1,580 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package caldavtest provides an in-process CalDAV server for tests.
+//
+// The server is built on the go-webdav server package, which handles
+// PROPFIND, calendar-query and calendar-multiget. It adds the CTag and
+// sync token collection properties and RFC 6578 sync-collection, which
+// go-webdav does not implement, so that incremental sync can be tested too.
+package caldavtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav"
+	"github.com/emersion/go-webdav/caldav"
+)
+
+const (
+	principalPath = "/user/"
+	homeSetPath   = "/user/calendars/"
+)
+
+// Server is a CalDAV server holding the calendars of a single user. Each
+// change bumps a revision number, used as CTag, sync token and ETag.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	revision  int
+	calendars map[string]*calendar
+	noSync    bool
+
+	// multigetFailures is the number of calendar-multiget reports left to
+	// fail
+	multigetFailures int
+}
+
+// calendar is a calendar collection of the server
+type calendar struct {
+	name       string
+	components []string
+	objects    map[string]*object
+	deleted    map[string]int
+	status     int
+}
+
+// object is a calendar object along with the revision that last changed it
+type object struct {
+	data     *ical.Calendar
+	revision int
+}
+
+// NewServer starts a server seeded from a directory: each subdirectory is
+// a calendar named after it, holding the .ics files within as objects. An
+// empty dir starts a server without calendars. The server is closed when
+// the test ends.
+func NewServer(t testing.TB, dir string) *Server {
+	t.Helper()
+
+	s := &Server{calendars: make(map[string]*calendar)}
+	if dir != "" {
+		if err := s.seed(dir); err != nil {
+			t.Fatalf("failed to seed CalDAV server from %s: %v", dir, err)
+		}
+	}
+
+	s.Server = httptest.NewServer(s)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// seed loads the calendars of a fixture directory
+func (s *Server) seed(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		s.AddCalendar(entry.Name())
+
+		files, err := filepath.Glob(filepath.Join(dir, entry.Name(), "*.ics"))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if err := s.Put(entry.Name(), filepath.Base(file), string(data)); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// URL returns the URL of a calendar collection
+func (s *Server) URL(name string) string {
+	return s.Server.URL + calendarPath(name)
+}
+
+// AddCalendar adds an empty calendar, if it does not exist yet, supporting
+// the given components, or VEVENT and VTODO by default
+func (s *Server) AddCalendar(name string, components ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.calendars[name]; ok {
+		return
+	}
+	s.revision++
+	if len(components) == 0 {
+		components = []string{ical.CompEvent, ical.CompToDo}
+	}
+	s.calendars[name] = &calendar{
+		name:       name,
+		components: components,
+		objects:    make(map[string]*object),
+		deleted:    make(map[string]int),
+	}
+}
+
+// Put adds or replaces an object of a calendar, creating the calendar if
+// needed
+func (s *Server) Put(name, file, data string) error {
+	cal, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		return err
+	}
+
+	s.AddCalendar(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revision++
+	c := s.calendars[name]
+	c.objects[file] = &object{data: cal, revision: s.revision}
+	delete(c.deleted, file)
+
+	return nil
+}
+
+// Delete removes an object of a calendar
+func (s *Server) Delete(name, file string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.calendars[name]
+	if !ok {
+		return
+	}
+	if _, ok := c.objects[file]; !ok {
+		return
+	}
+	s.revision++
+	delete(c.objects, file)
+	c.deleted[file] = s.revision
+}
+
+// Fail makes every request to a calendar fail with the given status, or
+// succeed again with status 0
+func (s *Server) Fail(name string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.calendars[name]; ok {
+		c.status = status
+	}
+}
+
+// DisableSync stops reporting CTags and sync tokens and rejects
+// sync-collection, so that clients fall back to comparing ETags
+func (s *Server) DisableSync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.noSync = true
+}
+
+// FailMultiGet makes the next n calendar-multiget reports fail with a 500
+// error, while other requests succeed
+func (s *Server) FailMultiGet(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.multigetFailures = n
+}
+
+// failMultiGet reports whether a calendar-multiget report must fail,
+// counting it
+func (s *Server) failMultiGet() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.multigetFailures == 0 {
+		return false
+	}
+	s.multigetFailures--
+	return true
+}
+
+// ServeHTTP serves the CalDAV requests, handling the collection properties
+// and sync-collection reports go-webdav does not support
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status := s.failure(r.URL.Path); status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	// go-webdav clients drop the trailing slash of the context path they
+	// are given, which its server does not match with the principal path
+	if r.URL.Path+"/" == principalPath {
+		r.URL.Path = principalPath
+	}
+
+	switch {
+	case r.Method == "REPORT" && bytes.Contains(body, []byte("calendar-multiget")) && s.failMultiGet():
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	case r.Method == "PROPFIND" && r.Header.Get("Depth") == "0" && bytes.Contains(body, []byte("getctag")):
+		s.serveCollectionProps(w, r)
+	case r.Method == "REPORT" && bytes.Contains(body, []byte("sync-collection")):
+		s.serveSyncCollection(w, r, body)
+	default:
+		handler := caldav.Handler{Backend: backend{s}}
+		handler.ServeHTTP(w, r)
+	}
+}
+
+// failure returns the status a request to the given path must fail with
+func (s *Server) failure(reqPath string) int {
+	name, _, ok := splitPath(reqPath)
+	if !ok {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.calendars[name]; ok {
+		return c.status
+	}
+	return 0
+}
+
+// multistatus is a WebDAV multi-status response body
+type multistatus struct {
+	XMLName   xml.Name   `xml:"d:multistatus"`
+	XMLNS     string     `xml:"xmlns:d,attr"`
+	XMLNSCS   string     `xml:"xmlns:cs,attr"`
+	Responses []response `xml:"d:response"`
+	SyncToken string     `xml:"d:sync-token,omitempty"`
+}
+
+// response is a single response within a multi-status body
+type response struct {
+	Href     string    `xml:"d:href"`
+	Status   string    `xml:"d:status,omitempty"`
+	Propstat *propstat `xml:"d:propstat"`
+}
+
+// propstat holds the properties of a response
+type propstat struct {
+	Prop   prop   `xml:"d:prop"`
+	Status string `xml:"d:status"`
+}
+
+// prop holds the properties the server adds to go-webdav's
+type prop struct {
+	GetCTag   string `xml:"cs:getctag,omitempty"`
+	SyncToken string `xml:"d:sync-token,omitempty"`
+	GetETag   string `xml:"d:getetag,omitempty"`
+}
+
+// serveCollectionProps reports the CTag and sync token of a calendar
+func (s *Server) serveCollectionProps(w http.ResponseWriter, r *http.Request) {
+	name, file, ok := splitPath(r.URL.Path)
+	if !ok || file != "" {
+		http.Error(w, "not a calendar", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	_, exists := s.calendars[name]
+	revision, noSync := s.revision, s.noSync
+	s.mu.Unlock()
+
+	if !exists {
+		http.Error(w, "calendar not found", http.StatusNotFound)
+		return
+	}
+
+	var p prop
+	if !noSync {
+		p.GetCTag = strconv.Itoa(revision)
+		p.SyncToken = syncToken(revision)
+	}
+
+	writeMultistatus(w, multistatus{
+		Responses: []response{{
+			Href:     r.URL.Path,
+			Propstat: &propstat{Prop: p, Status: "HTTP/1.1 200 OK"},
+		}},
+	})
+}
+
+// serveSyncCollection reports the objects changed and deleted since the
+// revision of the request sync token
+func (s *Server) serveSyncCollection(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req struct {
+		SyncToken string `xml:"DAV: sync-token"`
+	}
+	if err := xml.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name, file, ok := splitPath(r.URL.Path)
+	if !ok || file != "" {
+		http.Error(w, "not a calendar", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.calendars[name]
+	if !ok {
+		http.Error(w, "calendar not found", http.StatusNotFound)
+		return
+	}
+
+	since, err := strconv.Atoi(strings.TrimPrefix(req.SyncToken, "rev-"))
+	if s.noSync || !strings.HasPrefix(req.SyncToken, "rev-") || err != nil || since > s.revision {
+		http.Error(w, "valid-sync-token", http.StatusForbidden)
+		return
+	}
+
+	ms := multistatus{SyncToken: syncToken(s.revision)}
+	for _, file := range sortedKeys(c.objects) {
+		obj := c.objects[file]
+		if obj.revision <= since {
+			continue
+		}
+		ms.Responses = append(ms.Responses, response{
+			Href: calendarPath(name) + file,
+			Propstat: &propstat{
+				Prop:   prop{GetETag: etag(obj.revision)},
+				Status: "HTTP/1.1 200 OK",
+			},
+		})
+	}
+	for _, file := range sortedKeys(c.deleted) {
+		if c.deleted[file] <= since {
+			continue
+		}
+		ms.Responses = append(ms.Responses, response{
+			Href:   calendarPath(name) + file,
+			Status: "HTTP/1.1 404 Not Found",
+		})
+	}
+
+	writeMultistatus(w, ms)
+}
+
+// writeMultistatus writes a 207 Multi-Status response
+func writeMultistatus(w http.ResponseWriter, ms multistatus) {
+	ms.XMLNS = "DAV:"
+	ms.XMLNSCS = "http://calendarserver.org/ns/"
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(ms)
+}
+
+// backend implements the go-webdav CalDAV backend over the server calendars
+type backend struct {
+	s *Server
+}
+
+func (b backend) CurrentUserPrincipal(ctx context.Context) (string, error) {
+	return principalPath, nil
+}
+
+func (b backend) CalendarHomeSetPath(ctx context.Context) (string, error) {
+	return homeSetPath, nil
+}
+
+func (b backend) CreateCalendar(ctx context.Context, cal *caldav.Calendar) error {
+	return webdav.NewHTTPError(http.StatusForbidden, fmt.Errorf("read-only server"))
+}
+
+func (b backend) ListCalendars(ctx context.Context) ([]caldav.Calendar, error) {
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+
+	var cals []caldav.Calendar
+	for _, name := range sortedKeys(b.s.calendars) {
+		cals = append(cals, calendarInfo(b.s.calendars[name]))
+	}
+	return cals, nil
+}
+
+func (b backend) GetCalendar(ctx context.Context, reqPath string) (*caldav.Calendar, error) {
+	name, file, ok := splitPath(reqPath)
+	if !ok || file != "" {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("not a calendar: %s", reqPath))
+	}
+
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+
+	c, ok := b.s.calendars[name]
+	if !ok {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("calendar not found: %s", name))
+	}
+	cal := calendarInfo(c)
+	return &cal, nil
+}
+
+func (b backend) GetCalendarObject(ctx context.Context, reqPath string, req *caldav.CalendarCompRequest) (*caldav.CalendarObject, error) {
+	name, file, ok := splitPath(reqPath)
+	if !ok || file == "" {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("not a calendar object: %s", reqPath))
+	}
+
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+
+	c, ok := b.s.calendars[name]
+	if !ok {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("calendar not found: %s", name))
+	}
+	obj, ok := c.objects[file]
+	if !ok {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("object not found: %s", reqPath))
+	}
+	co := calendarObject(name, file, obj)
+	return &co, nil
+}
+
+func (b backend) ListCalendarObjects(ctx context.Context, reqPath string, req *caldav.CalendarCompRequest) ([]caldav.CalendarObject, error) {
+	name, _, ok := splitPath(reqPath)
+	if !ok {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("not a calendar: %s", reqPath))
+	}
+
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+
+	c, ok := b.s.calendars[name]
+	if !ok {
+		return nil, webdav.NewHTTPError(http.StatusNotFound, fmt.Errorf("calendar not found: %s", name))
+	}
+
+	var objects []caldav.CalendarObject
+	for _, file := range sortedKeys(c.objects) {
+		objects = append(objects, calendarObject(name, file, c.objects[file]))
+	}
+	return objects, nil
+}
+
+func (b backend) QueryCalendarObjects(ctx context.Context, reqPath string, query *caldav.CalendarQuery) ([]caldav.CalendarObject, error) {
+	objects, err := b.ListCalendarObjects(ctx, reqPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	return caldav.Filter(query, objects)
+}
+
+func (b backend) PutCalendarObject(ctx context.Context, reqPath string, cal *ical.Calendar, opts *caldav.PutCalendarObjectOptions) (*caldav.CalendarObject, error) {
+	return nil, webdav.NewHTTPError(http.StatusForbidden, fmt.Errorf("read-only server"))
+}
+
+func (b backend) DeleteCalendarObject(ctx context.Context, reqPath string) error {
+	return webdav.NewHTTPError(http.StatusForbidden, fmt.Errorf("read-only server"))
+}
+
+// calendarPath returns the path of a calendar collection
+func calendarPath(name string) string {
+	return homeSetPath + name + "/"
+}
+
+// splitPath splits a path within the home set into a calendar name and an
+// object file name, which is empty for the collection itself
+func splitPath(reqPath string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(path.Clean(reqPath), strings.TrimSuffix(homeSetPath, "/"))
+	if !ok {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return parts[0], "", true
+	case len(parts) == 2:
+		return parts[0], parts[1], true
+	}
+	return "", "", false
+}
+
+// calendarInfo describes a calendar collection to go-webdav
+func calendarInfo(c *calendar) caldav.Calendar {
+	return caldav.Calendar{
+		Path:                  calendarPath(c.name),
+		Name:                  c.name,
+		SupportedComponentSet: c.components,
+	}
+}
+
+// calendarObject describes a calendar object to go-webdav
+func calendarObject(name, file string, obj *object) caldav.CalendarObject {
+	return caldav.CalendarObject{
+		Path: calendarPath(name) + file,
+		ETag: etag(obj.revision),
+		Data: obj.data,
+	}
+}
+
+// etag returns the ETag of an object changed at the given revision
+func etag(revision int) string {
+	return "rev-" + strconv.Itoa(revision)
+}
+
+// syncToken returns the sync token of the given revision
+func syncToken(revision int) string {
+	return "rev-" + strconv.Itoa(revision)
+}
+
+// sortedKeys returns the keys of a map in order, so that responses are
+// deterministic
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -16,27 +16,217 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path"
+	"slices"
 	"strings"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	TimeZone    string     `yaml:"time_zone"`
-	AutoRefresh int        `yaml:"auto_refresh"`
-	Calendars   []Calendar `yaml:"calendars"`
+	TimeZone     string     `yaml:"time_zone"`
+	AutoRefresh  int        `yaml:"auto_refresh"`
+	SyncInterval int        `yaml:"sync_interval"`
+	Calendars    []Calendar `yaml:"calendars"`
+	Accounts     []Account  `yaml:"accounts"`
+	Auth         Auth       `yaml:"auth"`
+	Access       Access     `yaml:"access"`
+	Shares       Shares     `yaml:"shares"`
+	Log          Log        `yaml:"log"`
 }
 
-// Calendar represents a single CalDAV calendar configuration
+// Calendar source types
+const (
+	TypeCalDAV = "caldav"
+	TypeICS    = "ics"
+	TypeFile   = "file"
+	TypeVdir   = "vdir"
+)
+
+// Free/busy exposure of a calendar
+const (
+	FreeBusyDetails = "details"
+	FreeBusyBusy    = "busy"
+	FreeBusyNone    = "none"
+)
+
+// Privacy levels of a calendar, from the least to the most restrictive
+const (
+	PrivacyFull      = "full"
+	PrivacyTitleOnly = "title-only"
+	PrivacyBusy      = "busy"
+)
+
+// DefaultMaxSize is the default size limit of downloaded or local iCalendar
+// files
+const DefaultMaxSize = 10 << 20
+
+// DefaultPollInterval is how often local calendar files are checked for
+// changes, in seconds, unless a refresh interval is configured
+const DefaultPollInterval = 10
+
+// Calendar represents a single calendar configuration: a CalDAV collection,
+// a plain iCalendar file served over HTTP (type "ics"), a local iCalendar
+// file (type "file") or a local vdir directory (type "vdir")
 type Calendar struct {
-	Name         string `yaml:"name"`
-	URL          string `yaml:"url"`
-	UserID       string `yaml:"user_id"`
-	PasswordFile string `yaml:"password_file"`
-	Color        string `yaml:"color"`
+	Name            string `yaml:"name"`
+	Type            string `yaml:"type"`
+	URL             string `yaml:"url"`
+	Path            string `yaml:"path"`
+	UserID          string `yaml:"user_id"`
+	PasswordFile    string `yaml:"password_file"`
+	Color           string `yaml:"color"`
+	RefreshInterval int    `yaml:"refresh_interval"`
+	MaxSize         int64  `yaml:"max_size"`
+	FreeBusy        string `yaml:"free_busy"`
+	Privacy         string `yaml:"privacy"`
+	Required        bool   `yaml:"required"`
+
+	// Account is the name of the account the calendar was discovered from,
+	// empty for configured calendars
+	Account string `yaml:"-"`
+}
+
+// Account represents a CalDAV account whose calendars are discovered from
+// the server instead of being listed one by one
+type Account struct {
+	Name         string                      `yaml:"name"`
+	URL          string                      `yaml:"url"`
+	UserID       string                      `yaml:"user_id"`
+	PasswordFile string                      `yaml:"password_file"`
+	Color        string                      `yaml:"color"`
+	Include      []string                    `yaml:"include"`
+	Exclude      []string                    `yaml:"exclude"`
+	Overrides    map[string]CalendarOverride `yaml:"overrides"`
+}
+
+// CalendarOverride replaces the name and/or color a server reports for a
+// discovered calendar
+type CalendarOverride struct {
+	Name  string `yaml:"name"`
+	Color string `yaml:"color"`
+}
+
+// Authentication modes
+const (
+	AuthNone  = "none"
+	AuthLocal = "local"
+	AuthProxy = "proxy"
+	AuthOIDC  = "oidc"
+)
+
+// DefaultSessionTTL is how long login sessions last, in seconds, unless
+// configured otherwise
+const DefaultSessionTTL = 7 * 24 * 3600
+
+// Auth configures who may access the web UI and API: anyone (mode "none",
+// the default), local users with bcrypt password hashes, users authenticated
+// by a trusted reverse proxy, or users logging in with an OpenID Connect
+// provider
+type Auth struct {
+	Mode              string    `yaml:"mode"`
+	SessionSecretFile string    `yaml:"session_secret_file"`
+	SessionTTL        int       `yaml:"session_ttl"`
+	MetricsTokenFile  string    `yaml:"metrics_token_file"`
+	Users             []User    `yaml:"users"`
+	Proxy             ProxyAuth `yaml:"proxy"`
+	OIDC              OIDCAuth  `yaml:"oidc"`
+}
+
+// User is a local user, logging in with HTTP Basic authentication
+type User struct {
+	Username     string `yaml:"username"`
+	PasswordHash string `yaml:"password_hash"`
+}
+
+// ProxyAuth configures authentication by a reverse proxy, such as Authelia
+// or oauth2-proxy, passing the user name in a header. The header is only
+// trusted on connections from the given addresses or networks.
+type ProxyAuth struct {
+	UserHeader     string   `yaml:"user_header"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// OIDCAuth configures login with an OpenID Connect provider
+type OIDCAuth struct {
+	Issuer           string   `yaml:"issuer"`
+	ClientID         string   `yaml:"client_id"`
+	ClientSecretFile string   `yaml:"client_secret_file"`
+	RedirectURL      string   `yaml:"redirect_url"`
+	Scopes           []string `yaml:"scopes"`
+	UsernameClaim    string   `yaml:"username_claim"`
+	AllowedUsers     []string `yaml:"allowed_users"`
+}
+
+// Access restricts the calendars authenticated users see. Unless groups or
+// users are configured, everyone sees every calendar.
+type Access struct {
+	Groups []Group      `yaml:"groups"`
+	Users  []UserAccess `yaml:"users"`
+}
+
+// Group grants calendars, overrides and views to its members, given as glob
+// patterns on user names
+type Group struct {
+	Name    string   `yaml:"name"`
+	Members []string `yaml:"members"`
+	Grant   `yaml:",inline"`
+}
+
+// UserAccess grants calendars, overrides and views to a single user, on top
+// of those of their groups
+type UserAccess struct {
+	Username string `yaml:"username"`
+	Grant    `yaml:",inline"`
+}
+
+// Grant lists the calendars a user may see, as glob patterns on calendar
+// names, along with per-user names and colors and named views
+type Grant struct {
+	Calendars []string                    `yaml:"calendars"`
+	Overrides map[string]CalendarOverride `yaml:"overrides"`
+	Views     []View                      `yaml:"views"`
+}
+
+// View is a named subset of the calendars of a user, as glob patterns on
+// calendar names
+type View struct {
+	Name      string   `yaml:"name"`
+	Calendars []string `yaml:"calendars"`
+}
+
+// Log levels
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+// Log formats
+const (
+	LogText = "text"
+	LogJSON = "json"
+)
+
+// Log configures logging: the minimum level of logged messages, and whether
+// they are written as logfmt-style text or as JSON lines
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Shares configures read-only share links, giving access to some calendars
+// without an account. Links are kept in a state file, and are managed with
+// the share subcommand or, by admins, over the API.
+type Shares struct {
+	StateFile string   `yaml:"state_file"`
+	Admins    []string `yaml:"admins"`
 }
 
 // LoadConfig loads and validates the configuration from a YAML file
@@ -73,15 +263,62 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("auto_refresh must be positive")
 	}
 
+	// Validate sync_interval (optional, defaults to auto_refresh)
+	if c.SyncInterval < 0 {
+		return fmt.Errorf("sync_interval must not be negative")
+	}
+
 	// Validate calendars
-	if len(c.Calendars) == 0 {
-		return fmt.Errorf("at least one calendar is required")
+	if len(c.Calendars) == 0 && len(c.Accounts) == 0 {
+		return fmt.Errorf("at least one calendar or account is required")
 	}
 
+	// Calendars are told apart by name, by the cache, access rules and
+	// metrics alike
+	names := make(map[string]bool)
 	for i, cal := range c.Calendars {
 		if err := cal.Validate(); err != nil {
 			return fmt.Errorf("calendar %d (%s): %w", i, cal.Name, err)
 		}
+		if names[cal.Name] {
+			return fmt.Errorf("calendar %d: duplicate name %q", i, cal.Name)
+		}
+		names[cal.Name] = true
+	}
+
+	accounts := make(map[string]bool)
+	for i, acc := range c.Accounts {
+		if err := acc.Validate(); err != nil {
+			return fmt.Errorf("account %d (%s): %w", i, acc.Name, err)
+		}
+		if accounts[acc.Name] {
+			return fmt.Errorf("account %d: duplicate name %q", i, acc.Name)
+		}
+		accounts[acc.Name] = true
+	}
+
+	if err := c.Auth.Validate(); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+
+	if err := c.Access.Validate(); err != nil {
+		return fmt.Errorf("access: %w", err)
+	}
+	if c.Access.IsEnabled() && c.Auth.GetMode() == AuthNone {
+		return fmt.Errorf("access: authentication is required to restrict calendars per user")
+	}
+
+	if err := c.Log.Validate(); err != nil {
+		return fmt.Errorf("log: %w", err)
+	}
+
+	if len(c.Shares.Admins) > 0 {
+		if c.Shares.StateFile == "" {
+			return fmt.Errorf("shares: state_file is required to manage share links")
+		}
+		if c.Auth.GetMode() == AuthNone {
+			return fmt.Errorf("shares: authentication is required for admins")
+		}
 	}
 
 	return nil
@@ -92,47 +329,572 @@ func (c *Calendar) Validate() error {
 	if c.Name == "" {
 		return fmt.Errorf("name is required")
 	}
-	if c.URL == "" {
+
+	switch c.GetType() {
+	case TypeCalDAV:
+		if c.URL == "" {
+			return fmt.Errorf("url is required")
+		}
+		if c.UserID == "" {
+			return fmt.Errorf("user_id is required")
+		}
+		if c.PasswordFile == "" {
+			return fmt.Errorf("password_file is required")
+		}
+	case TypeICS:
+		if c.URL == "" {
+			return fmt.Errorf("url is required")
+		}
+		// Credentials are optional, but must be given together
+		if (c.UserID == "") != (c.PasswordFile == "") {
+			return fmt.Errorf("user_id and password_file must be given together")
+		}
+	case TypeFile, TypeVdir:
+		if c.Path == "" {
+			return fmt.Errorf("path is required")
+		}
+	default:
+		// Other types are registered by their sources, which check their
+		// own settings when created
+	}
+
+	if c.Color == "" {
+		return fmt.Errorf("color is required")
+	}
+	if err := validateColor(c.Color); err != nil {
+		return err
+	}
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("refresh_interval must not be negative")
+	}
+	if c.MaxSize < 0 {
+		return fmt.Errorf("max_size must not be negative")
+	}
+	switch c.GetFreeBusy() {
+	case FreeBusyDetails, FreeBusyBusy, FreeBusyNone:
+	default:
+		return fmt.Errorf("free_busy must be %q, %q or %q", FreeBusyDetails, FreeBusyBusy, FreeBusyNone)
+	}
+	switch c.GetPrivacy() {
+	case PrivacyFull, PrivacyTitleOnly, PrivacyBusy:
+	default:
+		return fmt.Errorf("privacy must be %q, %q or %q", PrivacyFull, PrivacyTitleOnly, PrivacyBusy)
+	}
+
+	return nil
+}
+
+// GetType returns the source type of the calendar, CalDAV by default
+func (c *Calendar) GetType() string {
+	if c.Type == "" {
+		return TypeCalDAV
+	}
+	return c.Type
+}
+
+// IsLocal reports whether the calendar is read from the local filesystem
+func (c *Calendar) IsLocal() bool {
+	switch c.GetType() {
+	case TypeFile, TypeVdir:
+		return true
+	}
+	return false
+}
+
+// GetRefreshInterval returns how often the calendar should be synced, or
+// zero to use the global sync interval. Local calendars are polled every
+// DefaultPollInterval seconds by default, since checking them is cheap.
+func (c *Calendar) GetRefreshInterval() time.Duration {
+	if c.RefreshInterval > 0 {
+		return time.Duration(c.RefreshInterval) * time.Second
+	}
+	if c.IsLocal() {
+		return DefaultPollInterval * time.Second
+	}
+	return 0
+}
+
+// GetMaxSize returns the size limit of downloaded or read files in bytes
+func (c *Calendar) GetMaxSize() int64 {
+	if c.MaxSize > 0 {
+		return c.MaxSize
+	}
+	return DefaultMaxSize
+}
+
+// GetFreeBusy returns how the calendar is exposed by free/busy queries: with
+// the titles of its events (the default), as busy time only, or not at all
+func (c *Calendar) GetFreeBusy() string {
+	if c.FreeBusy == "" {
+		return FreeBusyDetails
+	}
+	return c.FreeBusy
+}
+
+// GetPrivacy returns how much of the calendar's events is shown: everything
+// (the default), only their titles, or only busy blocks
+func (c *Calendar) GetPrivacy() string {
+	if c.Privacy == "" {
+		return PrivacyFull
+	}
+	return c.Privacy
+}
+
+// Validate validates a single account configuration
+func (a *Account) Validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if a.URL == "" {
 		return fmt.Errorf("url is required")
 	}
-	if c.UserID == "" {
+	if a.UserID == "" {
 		return fmt.Errorf("user_id is required")
 	}
-	if c.PasswordFile == "" {
+	if a.PasswordFile == "" {
 		return fmt.Errorf("password_file is required")
 	}
-	if c.Color == "" {
-		return fmt.Errorf("color is required")
+	if a.Color != "" {
+		if err := validateColor(a.Color); err != nil {
+			return err
+		}
+	}
+
+	if err := validatePatterns(append(append([]string{}, a.Include...), a.Exclude...)); err != nil {
+		return err
+	}
+
+	for name, override := range a.Overrides {
+		if override.Color != "" {
+			if err := validateColor(override.Color); err != nil {
+				return fmt.Errorf("override %s: %w", name, err)
+			}
+		}
 	}
+
+	return nil
+}
+
+// Matches reports whether a discovered calendar with the given display name
+// passes the account's include and exclude patterns
+func (a *Account) Matches(name string) bool {
+	if len(a.Include) > 0 && !matchAny(a.Include, name) {
+		return false
+	}
+	return !matchAny(a.Exclude, name)
+}
+
+// matchAny reports whether name matches any of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePatterns checks that glob patterns are well formed
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// validateColor checks that a color is in hex format
+func validateColor(color string) error {
 	// Basic color validation (should start with #)
-	if !strings.HasPrefix(c.Color, "#") || len(c.Color) != 7 {
+	if !strings.HasPrefix(color, "#") || len(color) != 7 {
 		return fmt.Errorf("color must be in hex format (#RRGGBB)")
 	}
-
 	return nil
 }
 
 // GetPassword reads the password from the configured password file
 func (c *Calendar) GetPassword() (string, error) {
-	data, err := os.ReadFile(c.PasswordFile)
+	return readPasswordFile(c.PasswordFile)
+}
+
+// GetPassword reads the password from the configured password file
+func (a *Account) GetPassword() (string, error) {
+	return readPasswordFile(a.PasswordFile)
+}
+
+// PasswordFiles returns the password files of the calendars and accounts,
+// each listed once
+func (c *Config) PasswordFiles() []string {
+	var files []string
+	add := func(file string) {
+		if file != "" && !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+	for _, cal := range c.Calendars {
+		add(cal.PasswordFile)
+	}
+	for _, acc := range c.Accounts {
+		add(acc.PasswordFile)
+	}
+	return files
+}
+
+// readPasswordFile reads a password from a file containing only the password
+func readPasswordFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
 	if err != nil {
-		return "", fmt.Errorf("failed to read password file %s: %w", c.PasswordFile, err)
+		return "", fmt.Errorf("failed to read password file %s: %w", file, err)
 	}
 
 	// Trim any whitespace/newlines
 	password := strings.TrimSpace(string(data))
 	if password == "" {
-		return "", fmt.Errorf("password file %s is empty", c.PasswordFile)
+		return "", fmt.Errorf("password file %s is empty", file)
 	}
 
 	return password, nil
 }
 
+// Validate validates the authentication configuration
+func (a *Auth) Validate() error {
+	if a.SessionTTL < 0 {
+		return fmt.Errorf("session_ttl must not be negative")
+	}
+
+	switch a.GetMode() {
+	case AuthNone:
+	case AuthLocal:
+		if len(a.Users) == 0 {
+			return fmt.Errorf("at least one user is required")
+		}
+		seen := make(map[string]bool)
+		for i, user := range a.Users {
+			if user.Username == "" {
+				return fmt.Errorf("user %d: username is required", i)
+			}
+			if seen[user.Username] {
+				return fmt.Errorf("user %d: duplicate username %s", i, user.Username)
+			}
+			seen[user.Username] = true
+			if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil {
+				return fmt.Errorf("user %d (%s): password_hash must be a bcrypt hash: %w", i, user.Username, err)
+			}
+		}
+	case AuthProxy:
+		if len(a.Proxy.TrustedProxies) == 0 {
+			return fmt.Errorf("proxy.trusted_proxies is required")
+		}
+		if _, err := a.Proxy.TrustedNetworks(); err != nil {
+			return fmt.Errorf("proxy.trusted_proxies: %w", err)
+		}
+	case AuthOIDC:
+		if a.OIDC.Issuer == "" {
+			return fmt.Errorf("oidc.issuer is required")
+		}
+		if a.OIDC.ClientID == "" {
+			return fmt.Errorf("oidc.client_id is required")
+		}
+		if a.OIDC.ClientSecretFile == "" {
+			return fmt.Errorf("oidc.client_secret_file is required")
+		}
+		if a.OIDC.RedirectURL == "" {
+			return fmt.Errorf("oidc.redirect_url is required")
+		}
+	default:
+		return fmt.Errorf("mode must be %q, %q, %q or %q", AuthNone, AuthLocal, AuthProxy, AuthOIDC)
+	}
+
+	return nil
+}
+
+// GetMode returns the authentication mode, none by default
+func (a *Auth) GetMode() string {
+	if a.Mode == "" {
+		return AuthNone
+	}
+	return a.Mode
+}
+
+// GetSessionTTL returns how long login sessions last
+func (a *Auth) GetSessionTTL() time.Duration {
+	if a.SessionTTL > 0 {
+		return time.Duration(a.SessionTTL) * time.Second
+	}
+	return DefaultSessionTTL * time.Second
+}
+
+// GetSessionSecret reads the key signing session cookies, or returns nil if
+// none is configured
+func (a *Auth) GetSessionSecret() ([]byte, error) {
+	if a.SessionSecretFile == "" {
+		return nil, nil
+	}
+	secret, err := readPasswordFile(a.SessionSecretFile)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(secret), nil
+}
+
+// GetMetricsToken reads the bearer token Prometheus scrapes the metrics
+// with, or returns "" if none is configured
+func (a *Auth) GetMetricsToken() (string, error) {
+	if a.MetricsTokenFile == "" {
+		return "", nil
+	}
+	return readPasswordFile(a.MetricsTokenFile)
+}
+
+// GetUserHeader returns the header holding the user name, Remote-User by
+// default
+func (p *ProxyAuth) GetUserHeader() string {
+	if p.UserHeader == "" {
+		return "Remote-User"
+	}
+	return p.UserHeader
+}
+
+// TrustedNetworks parses the trusted proxies, given as IP addresses or CIDR
+// networks
+func (p *ProxyAuth) TrustedNetworks() ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, proxy := range p.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// GetScopes returns the scopes requested from the provider
+func (o *OIDCAuth) GetScopes() []string {
+	if len(o.Scopes) == 0 {
+		return []string{"openid", "profile", "email"}
+	}
+	return o.Scopes
+}
+
+// GetUsernameClaim returns the ID token claim holding the user name,
+// preferred_username by default
+func (o *OIDCAuth) GetUsernameClaim() string {
+	if o.UsernameClaim == "" {
+		return "preferred_username"
+	}
+	return o.UsernameClaim
+}
+
+// GetClientSecret reads the client secret from the configured file
+func (o *OIDCAuth) GetClientSecret() (string, error) {
+	return readPasswordFile(o.ClientSecretFile)
+}
+
+// Validate validates the groups and users of the access configuration
+func (a *Access) Validate() error {
+	groups := make(map[string]bool)
+	for i, group := range a.Groups {
+		if group.Name == "" {
+			return fmt.Errorf("group %d: name is required", i)
+		}
+		if groups[group.Name] {
+			return fmt.Errorf("group %d: duplicate name %s", i, group.Name)
+		}
+		groups[group.Name] = true
+		if len(group.Members) == 0 {
+			return fmt.Errorf("group %d (%s): at least one member is required", i, group.Name)
+		}
+		if err := validatePatterns(group.Members); err != nil {
+			return fmt.Errorf("group %d (%s): members: %w", i, group.Name, err)
+		}
+		if err := group.Grant.Validate(); err != nil {
+			return fmt.Errorf("group %d (%s): %w", i, group.Name, err)
+		}
+	}
+
+	users := make(map[string]bool)
+	for i, user := range a.Users {
+		if user.Username == "" {
+			return fmt.Errorf("user %d: username is required", i)
+		}
+		if users[user.Username] {
+			return fmt.Errorf("user %d: duplicate username %s", i, user.Username)
+		}
+		users[user.Username] = true
+		if err := user.Grant.Validate(); err != nil {
+			return fmt.Errorf("user %d (%s): %w", i, user.Username, err)
+		}
+	}
+
+	return nil
+}
+
+// IsEnabled reports whether calendars are restricted per user
+func (a *Access) IsEnabled() bool {
+	return len(a.Groups) > 0 || len(a.Users) > 0
+}
+
+// GrantFor merges the grants of the groups of a user and of their own entry.
+// Overrides of the user entry take precedence over those of groups, and
+// views keep the first definition of each name.
+func (a *Access) GrantFor(username string) Grant {
+	var merged Grant
+	add := func(g Grant) {
+		merged.Calendars = append(merged.Calendars, g.Calendars...)
+		for name, override := range g.Overrides {
+			if merged.Overrides == nil {
+				merged.Overrides = make(map[string]CalendarOverride)
+			}
+			merged.Overrides[name] = override
+		}
+		for _, view := range g.Views {
+			if _, ok := merged.View(view.Name); !ok {
+				merged.Views = append(merged.Views, view)
+			}
+		}
+	}
+
+	for _, group := range a.Groups {
+		if matchAny(group.Members, username) {
+			add(group.Grant)
+		}
+	}
+	for _, user := range a.Users {
+		if user.Username == username {
+			add(user.Grant)
+		}
+	}
+
+	return merged
+}
+
+// Validate validates the calendar patterns, overrides and views of a grant
+func (g *Grant) Validate() error {
+	if err := validatePatterns(g.Calendars); err != nil {
+		return fmt.Errorf("calendars: %w", err)
+	}
+
+	for name, override := range g.Overrides {
+		if override.Color != "" {
+			if err := validateColor(override.Color); err != nil {
+				return fmt.Errorf("override %s: %w", name, err)
+			}
+		}
+	}
+
+	views := make(map[string]bool)
+	for i, view := range g.Views {
+		if view.Name == "" {
+			return fmt.Errorf("view %d: name is required", i)
+		}
+		if views[view.Name] {
+			return fmt.Errorf("view %d: duplicate name %s", i, view.Name)
+		}
+		views[view.Name] = true
+		if len(view.Calendars) == 0 {
+			return fmt.Errorf("view %d (%s): at least one calendar is required", i, view.Name)
+		}
+		if err := validatePatterns(view.Calendars); err != nil {
+			return fmt.Errorf("view %d (%s): calendars: %w", i, view.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Allows reports whether the grant includes the calendar with the given
+// name
+func (g *Grant) Allows(name string) bool {
+	return matchAny(g.Calendars, name)
+}
+
+// View returns the view with the given name
+func (g *Grant) View(name string) (View, bool) {
+	for _, view := range g.Views {
+		if view.Name == name {
+			return view, true
+		}
+	}
+	return View{}, false
+}
+
+// Includes reports whether the view shows the calendar with the given name
+func (v *View) Includes(name string) bool {
+	return matchAny(v.Calendars, name)
+}
+
+// IsEnabled reports whether share links are accepted
+func (s *Shares) IsEnabled() bool {
+	return s.StateFile != ""
+}
+
+// IsAdmin reports whether a user may manage share links over the API
+func (s *Shares) IsAdmin(user string) bool {
+	return user != "" && slices.Contains(s.Admins, user)
+}
+
+// Validate validates the logging configuration
+func (l *Log) Validate() error {
+	switch l.GetLevel() {
+	case LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		return fmt.Errorf("level must be %q, %q, %q or %q", LogDebug, LogInfo, LogWarn, LogError)
+	}
+
+	switch l.GetFormat() {
+	case LogText, LogJSON:
+	default:
+		return fmt.Errorf("format must be %q or %q", LogText, LogJSON)
+	}
+
+	return nil
+}
+
+// GetLevel returns the minimum level of logged messages, info by default
+func (l *Log) GetLevel() string {
+	if l.Level == "" {
+		return LogInfo
+	}
+	return l.Level
+}
+
+// GetFormat returns the format of log lines, text by default
+func (l *Log) GetFormat() string {
+	if l.Format == "" {
+		return LogText
+	}
+	return l.Format
+}
+
 // GetLocation returns the time.Location for the configured timezone
 func (c *Config) GetLocation() (*time.Location, error) {
 	return time.LoadLocation(c.TimeZone)
 }
 
+// GetSyncInterval returns how often calendars are synced in the background,
+// defaulting to the auto-refresh interval
+func (c *Config) GetSyncInterval() time.Duration {
+	if c.SyncInterval > 0 {
+		return time.Duration(c.SyncInterval) * time.Second
+	}
+	return time.Duration(c.AutoRefresh) * time.Second
+}
+
 // Sanitize returns a sanitized version of the config (without credentials)
 // for sending to the frontend
 func (c *Config) Sanitize() map[string]interface{} {
@@ -148,5 +910,6 @@ func (c *Config) Sanitize() map[string]interface{} {
 		"timezone":    c.TimeZone,
 		"autoRefresh": c.AutoRefresh,
 		"calendars":   cals,
+		"auth":        c.Auth.GetMode(),
 	}
 }
@@ -0,0 +1,90 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Requests.\nBy route.", "route", "status")
+	duration := r.NewHistogramVec("test_duration_seconds", "Duration.", []float64{1, 0.5}, "route")
+
+	requests.Inc("/b", "200")
+	requests.Add(2, "/a", "404")
+	requests.Inc(`say "hi"\`, "200")
+	duration.Observe(0.2, "/a")
+	duration.Observe(0.7, "/a")
+	duration.Observe(3, "/a")
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP test_requests_total Requests.\nBy route.
+# TYPE test_requests_total counter
+test_requests_total{route="/a",status="404"} 2
+test_requests_total{route="/b",status="200"} 1
+test_requests_total{route="say \"hi\"\\",status="200"} 1
+# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/a",le="0.5"} 1
+test_duration_seconds_bucket{route="/a",le="1"} 2
+test_duration_seconds_bucket{route="/a",le="+Inf"} 3
+test_duration_seconds_sum{route="/a"} 3.9
+test_duration_seconds_count{route="/a"} 3
+`
+	if b.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	if got := requests.Value("/a", "404"); got != 2 {
+		t.Errorf("Value = %v, want 2", got)
+	}
+	if got := duration.Count("/b"); got != 0 {
+		t.Errorf("Count of an unobserved series = %d, want 0", got)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Requests.", "calendar", "result")
+	duration := r.NewHistogramVec("test_duration_seconds", "Duration.", []float64{1}, "calendar")
+	traffic := r.NewCounterVec("test_traffic_total", "Traffic.", "route")
+
+	requests.Inc("work", "hit")
+	requests.Inc("work", "miss")
+	requests.Inc("personal", "hit")
+	duration.Observe(0.5, "work")
+	traffic.Inc("work")
+
+	r.Delete("calendar", "work")
+
+	if got := requests.Value("work", "hit") + requests.Value("work", "miss"); got != 0 {
+		t.Errorf("deleted counters = %v, want 0", got)
+	}
+	if got := duration.Count("work"); got != 0 {
+		t.Errorf("deleted histogram count = %d, want 0", got)
+	}
+	if got := requests.Value("personal", "hit"); got != 1 {
+		t.Errorf("counter of another calendar = %v, want 1", got)
+	}
+	if got := traffic.Value("work"); got != 1 {
+		t.Errorf("counter without the label = %v, want 1", got)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package share
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mano/mucal/internal/config"
+)
+
+// ErrNotFound is returned when revoking an unknown share
+var ErrNotFound = errors.New("share not found")
+
+// Share is a read-only link to some calendars, shown with at least the given
+// privacy level. Only the hash of its token is kept, so the token cannot be
+// recovered from the state file.
+type Share struct {
+	ID        string     `json:"id"`
+	Label     string     `json:"label,omitempty"`
+	TokenHash string     `json:"tokenHash"`
+	Calendars []string   `json:"calendars"`
+	Privacy   string     `json:"privacy"`
+	Created   time.Time  `json:"created"`
+	Expires   *time.Time `json:"expires,omitempty"`
+}
+
+// Expired reports whether the share has expired at the given time
+func (s *Share) Expired(now time.Time) bool {
+	return s.Expires != nil && !now.Before(*s.Expires)
+}
+
+// Store keeps the shares in a JSON state file. Changes made to the file by
+// another process, such as the share subcommand while the server runs, are
+// picked up on the next lookup.
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	shares  []Share
+	modTime time.Time
+	size    int64
+}
+
+// Open opens the store kept in the given file, which is created on the first
+// change
+func Open(file string) (*Store, error) {
+	s := &Store{path: file}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create adds a share of the calendars matching the given glob patterns,
+// returning it along with its token
+func (s *Store) Create(label string, calendars []string, privacy string, expires *time.Time) (Share, string, error) {
+	if len(calendars) == 0 {
+		return Share{}, "", fmt.Errorf("at least one calendar is required")
+	}
+	for _, pattern := range calendars {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Share{}, "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if privacy == "" {
+		privacy = config.PrivacyFull
+	}
+	switch privacy {
+	case config.PrivacyFull, config.PrivacyTitleOnly, config.PrivacyBusy:
+	default:
+		return Share{}, "", fmt.Errorf("privacy must be %q, %q or %q", config.PrivacyFull, config.PrivacyTitleOnly, config.PrivacyBusy)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(randomBytes(32))
+	share := Share{
+		ID:        hex.EncodeToString(randomBytes(6)),
+		Label:     label,
+		TokenHash: hashToken(token),
+		Calendars: calendars,
+		Privacy:   privacy,
+		Created:   time.Now().UTC().Truncate(time.Second),
+		Expires:   expires,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return Share{}, "", err
+	}
+	shares := append(slices.Clone(s.shares), share)
+	if err := s.save(shares); err != nil {
+		return Share{}, "", err
+	}
+
+	return share, token, nil
+}
+
+// List returns the shares, including expired ones
+func (s *Store) List() ([]Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(s.shares), nil
+}
+
+// Revoke removes the share with the given ID
+func (s *Store) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(s.shares, func(share Share) bool { return share.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	return s.save(slices.Delete(slices.Clone(s.shares), i, i+1))
+}
+
+// Lookup returns the unexpired share with the given token. If the state
+// file cannot be read, no share is accepted until it is fixed.
+func (s *Store) Lookup(token string) (Share, bool) {
+	if token == "" {
+		return Share{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		slog.Error("failed to read shares", "error", err)
+		s.shares = nil
+		return Share{}, false
+	}
+
+	hash := hashToken(token)
+	for _, share := range s.shares {
+		if share.TokenHash == hash {
+			return share, !share.Expired(time.Now())
+		}
+	}
+	return Share{}, false
+}
+
+// refresh reloads the state file if it changed since it was last read
+func (s *Store) refresh() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.shares, s.modTime, s.size = nil, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+	return s.reload()
+}
+
+// reload reads the state file, a missing file holding no shares
+func (s *Store) reload() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.shares, s.modTime, s.size = nil, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+
+	var state struct {
+		Shares []Share `json:"shares"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	s.shares, s.modTime, s.size = state.Shares, info.ModTime(), info.Size()
+	return nil
+}
+
+// save replaces the state file with the given shares, through a temporary
+// file so that readers never see it half written
+func (s *Store) save(shares []Share) error {
+	data, err := json.MarshalIndent(map[string][]Share{"shares": shares}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode shares: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".shares-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write shares: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write shares: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write shares: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write shares: %w", err)
+	}
+
+	return s.reload()
+}
+
+// hashToken returns the hex SHA-256 hash of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomBytes returns n random bytes
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
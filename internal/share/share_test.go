@@ -0,0 +1,109 @@
+// Copyright 2026 Mano
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package share
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "shares.json")
+	store, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sh, token, err := store.Create("contractors", []string{"Work*"}, "title-only", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := store.Lookup(token); !ok || got.ID != sh.ID || got.Privacy != "title-only" {
+		t.Errorf("Lookup = %+v, %v, want share %s", got, ok, sh.ID)
+	}
+	if _, ok := store.Lookup(token + "x"); ok {
+		t.Error("Lookup accepts a wrong token")
+	}
+
+	// Only the hash of the token is stored
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), token) {
+		t.Error("state file holds the token")
+	}
+
+	// Changes made by another process are picked up
+	other, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Revoke(sh.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Lookup(token); ok {
+		t.Error("Lookup accepts a share revoked by another store")
+	}
+	if err := store.Revoke(sh.ID); err != ErrNotFound {
+		t.Errorf("Revoke of a revoked share = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "shares.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	_, token, err := store.Create("", []string{"Work"}, "", &past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Lookup(token); ok {
+		t.Error("Lookup accepts an expired share")
+	}
+
+	if _, _, err := store.Create("", []string{"Work"}, "secret", nil); err == nil {
+		t.Error("Create accepts an unknown privacy level")
+	}
+	if _, _, err := store.Create("", nil, "", nil); err == nil {
+		t.Error("Create accepts a share without calendars")
+	}
+}
+
+func TestStoreInvalidFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "shares.json")
+	store, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, token, err := store.Create("", []string{"Work"}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A broken state file revokes every share rather than keeping old ones
+	if err := os.WriteFile(file, []byte("{broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Lookup(token); ok {
+		t.Error("Lookup accepts a share of a broken state file")
+	}
+}
@@ -14,15 +14,24 @@
 
 // API service layer for μCal
 
-import type { Config, Event, APIError } from '../types';
+import type { Config, Event, Task, APIError, ChangeNotification, CalendarWarning } from '../types';
 import { format } from 'date-fns';
 
-const API_BASE = '/api';
+// Pages opened from a share link (/s/{token}/) make their requests through it
+const SHARE_PREFIX = window.location.pathname.match(/^\/s\/[^/]+/)?.[0] ?? '';
+const API_BASE = `${SHARE_PREFIX}/api`;
 
 // Fetch wrapper with error handling
 async function fetchAPI<T>(url: string): Promise<T> {
   const response = await fetch(url);
 
+  // Expired sessions are sent back to the login page
+  const loginURL = response.headers.get('X-Login-URL');
+  if (response.status === 401 && loginURL) {
+    const next = window.location.pathname + window.location.search;
+    window.location.href = `${loginURL}?next=${encodeURIComponent(next)}`;
+  }
+
   if (!response.ok) {
     const error: APIError = await response.json().catch(() => ({
       error: `HTTP ${response.status}: ${response.statusText}`,
@@ -39,23 +48,45 @@ export async function fetchConfig(): Promise<Config> {
   return fetchAPI<Config>(`${API_BASE}/config`);
 }
 
+// Query parameter selecting a view, empty for all calendars
+function viewParam(view: string): string {
+  return view ? `&view=${encodeURIComponent(view)}` : '';
+}
+
+// Items of a response, along with the calendars that could not be fetched
+export interface WithWarnings<T> {
+  items: T[];
+  warnings: CalendarWarning[];
+}
+
 // Fetch events for a date range
-export async function fetchEvents(start: Date, end: Date): Promise<Event[]> {
+export async function fetchEvents(start: Date, end: Date, view = ''): Promise<WithWarnings<Event>> {
   const startStr = format(start, 'yyyy-MM-dd');
   const endStr = format(end, 'yyyy-MM-dd');
-  const response = await fetchAPI<{ events: Event[] }>(
-    `${API_BASE}/events?start=${startStr}&end=${endStr}`
+  const response = await fetchAPI<{ events: Event[] | null; warnings?: CalendarWarning[] }>(
+    `${API_BASE}/events?start=${startStr}&end=${endStr}${viewParam(view)}`
   );
-  return response.events;
+  return { items: response.events ?? [], warnings: response.warnings ?? [] };
+}
+
+// Fetch open tasks for a date range (overdue ones are included on today)
+export async function fetchTasks(start: Date, end: Date, view = ''): Promise<WithWarnings<Task>> {
+  const startStr = format(start, 'yyyy-MM-dd');
+  const endStr = format(end, 'yyyy-MM-dd');
+  const response = await fetchAPI<{ tasks: Task[] | null; warnings?: CalendarWarning[] }>(
+    `${API_BASE}/tasks?start=${startStr}&end=${endStr}${viewParam(view)}`
+  );
+  return { items: response.tasks ?? [], warnings: response.warnings ?? [] };
 }
 
 // Fetch days with events for a month
 export async function fetchMonthEventDays(
   year: number,
-  month: number
+  month: number,
+  view = ''
 ): Promise<number[]> {
   const response = await fetchAPI<{ days: number[] }>(
-    `${API_BASE}/events/month?year=${year}&month=${month}`
+    `${API_BASE}/events/month?year=${year}&month=${month}${viewParam(view)}`
   );
   return response.days;
 }
@@ -64,3 +95,24 @@ export async function fetchMonthEventDays(
 export async function fetchHealth(): Promise<{ status: string; version: string }> {
   return fetchAPI<{ status: string; version: string }>(`${API_BASE}/health`);
 }
+
+// Subscribe to calendar change notifications (server-sent events).
+// onStatus reports whether the stream is currently connected.
+export function subscribeChanges(
+  onChange: (change: ChangeNotification) => void,
+  onStatus: (connected: boolean) => void
+): () => void {
+  const source = new EventSource(`${API_BASE}/stream`);
+
+  source.onopen = () => onStatus(true);
+  source.onerror = () => onStatus(false);
+  source.addEventListener('change', (e) => {
+    try {
+      onChange(JSON.parse((e as MessageEvent).data));
+    } catch (error) {
+      console.error('Invalid change notification:', error);
+    }
+  });
+
+  return () => source.close();
+}
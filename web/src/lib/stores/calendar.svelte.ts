@@ -14,21 +14,29 @@
 
 // Calendar store using Svelte 5 runes
 
-import type { Config, Event } from '../types';
-import { fetchConfig, fetchEvents, fetchMonthEventDays, fetchHealth } from '../services/api';
+import type { Config, Event, Task, ChangeNotification, DateRange, CalendarWarning } from '../types';
+import { fetchConfig, fetchEvents, fetchTasks, fetchMonthEventDays, fetchHealth } from '../services/api';
 import { getWeekStart, getWeekEnd, getWeekDays } from '../utils/date';
 import { errorStore } from './error.svelte';
-import { addDays } from 'date-fns';
+import { addDays, isToday } from 'date-fns';
 
 export class CalendarStore {
   // State
   currentDate = $state(new Date());
   selectedWeekStart = $state(getWeekStart(new Date()));
   events = $state<Event[]>([]);
+  tasks = $state<Task[]>([]);
   monthEventDays = $state<number[]>([]);
   loading = $state(false);
   config = $state<Config | null>(null);
   version = $state<string>('');
+  streamConnected = $state(false);
+  // Selected view of the user, empty for all their calendars; kept in the
+  // URL so that it can be bookmarked
+  view = $state(new URLSearchParams(window.location.search).get('view') ?? '');
+
+  // Month shown by the month calendar, to refresh its markers on changes
+  private monthShown: { year: number; month: number } | null = null;
 
   // Derived state
   weekEnd = $derived(getWeekEnd(this.selectedWeekStart));
@@ -37,8 +45,11 @@ export class CalendarStore {
   // Initialize: load config and initial events
   async init() {
     try {
-      // Load config
+      // Load config, forgetting views the user no longer has
       this.config = await fetchConfig();
+      if (!this.config.views.includes(this.view)) {
+        this.view = '';
+      }
 
       // Load version
       const health = await fetchHealth();
@@ -59,7 +70,20 @@ export class CalendarStore {
 
     this.loading = true;
     try {
-      this.events = await fetchEvents(this.selectedWeekStart, this.weekEnd);
+      const [events, tasks] = await Promise.all([
+        fetchEvents(this.selectedWeekStart, this.weekEnd, this.view),
+        fetchTasks(this.selectedWeekStart, this.weekEnd, this.view),
+      ]);
+      this.events = events.items;
+      this.tasks = tasks.items;
+
+      // Calendars that could not be fetched are missing from the week
+      const message = unavailableMessage([...events.warnings, ...tasks.warnings]);
+      if (message) {
+        errorStore.showWarning(message);
+      } else {
+        errorStore.clearWarning();
+      }
     } catch (error) {
       errorStore.showError(
         error instanceof Error ? error.message : 'Failed to load events'
@@ -71,14 +95,50 @@ export class CalendarStore {
 
   // Load month event days for the month calendar
   async loadMonthEventDays(year: number, month: number) {
+    this.monthShown = { year, month };
     try {
-      this.monthEventDays = await fetchMonthEventDays(year, month);
+      this.monthEventDays = await fetchMonthEventDays(year, month, this.view);
     } catch (error) {
       // Silently fail for month markers
       console.error('Failed to load month event days:', error);
     }
   }
 
+  // Re-fetch only the data overlapping the ranges of a change notification
+  handleChange(change: ChangeNotification) {
+    const weekEnd = addDays(this.selectedWeekStart, 7);
+    if (change.ranges.some(range => overlaps(range, this.selectedWeekStart, weekEnd))) {
+      this.loadEvents();
+    }
+
+    if (this.monthShown) {
+      const { year, month } = this.monthShown;
+      const monthStart = new Date(year, month - 1, 1);
+      const monthEnd = new Date(year, month, 1);
+      if (change.ranges.some(range => overlaps(range, monthStart, monthEnd))) {
+        this.loadMonthEventDays(year, month);
+      }
+    }
+  }
+
+  // Select a view, or all calendars with an empty name
+  selectView(view: string) {
+    this.view = view;
+
+    const url = new URL(window.location.href);
+    if (view) {
+      url.searchParams.set('view', view);
+    } else {
+      url.searchParams.delete('view');
+    }
+    window.history.replaceState(null, '', url);
+
+    this.loadEvents();
+    if (this.monthShown) {
+      this.loadMonthEventDays(this.monthShown.year, this.monthShown.month);
+    }
+  }
+
   // Select a new week
   selectWeek(date: Date) {
     this.selectedWeekStart = getWeekStart(date);
@@ -117,6 +177,41 @@ export class CalendarStore {
              eventDate.getDate() === day;
     });
   }
+
+  // Get tasks for a specific day: due that day, or overdue if it is today
+  getTasksForDay(date: Date): Task[] {
+    const year = date.getFullYear();
+    const month = date.getMonth();
+    const day = date.getDate();
+    const today = isToday(date);
+
+    return this.tasks.filter(task => {
+      if (task.overdue) return today;
+
+      const when = task.due ?? task.start;
+      if (!when) return false;
+
+      const taskDate = new Date(when);
+      return taskDate.getFullYear() === year &&
+             taskDate.getMonth() === month &&
+             taskDate.getDate() === day;
+    });
+  }
+}
+
+// Describe the calendars of some warnings, such as "Work calendar
+// unavailable", or return an empty string without warnings
+function unavailableMessage(warnings: CalendarWarning[]): string {
+  const names = [...new Set(warnings.map(warning => warning.calendar))];
+  if (names.length === 0) return '';
+  if (names.length === 1) return `${names[0]} calendar unavailable`;
+  return `${names.slice(0, -1).join(', ')} and ${names[names.length - 1]} calendars unavailable`;
+}
+
+// Check whether a (possibly unbounded) range overlaps [start, end)
+function overlaps(range: DateRange, start: Date, end: Date): boolean {
+  return (!range.start || new Date(range.start) < end) &&
+         (!range.end || new Date(range.end) > start);
 }
 
 export const calendarStore = new CalendarStore();
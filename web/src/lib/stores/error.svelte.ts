@@ -17,12 +17,30 @@
 export class ErrorStore {
   message = $state<string | null>(null);
   show = $state(false);
+  // Warnings report partial failures, such as an unavailable calendar
+  warning = $state(false);
 
   showError(msg: string) {
     this.message = msg;
+    this.warning = false;
     this.show = true;
   }
 
+  // Show a warning, unless an error is shown
+  showWarning(msg: string) {
+    if (this.show && !this.warning) return;
+    this.message = msg;
+    this.warning = true;
+    this.show = true;
+  }
+
+  // Hide the warning shown, if any, once the failure is over
+  clearWarning() {
+    if (this.show && this.warning) {
+      this.clearError();
+    }
+  }
+
   clearError() {
     this.show = false;
     // Clear message after animation
@@ -22,11 +22,43 @@ export interface Event {
   start: string; // ISO 8601 timestamp
   end: string; // ISO 8601 timestamp
   allDay: boolean;
+  status: string; // TENTATIVE, CONFIRMED, CANCELLED, empty if undefined
+  transparency: string; // OPAQUE, TRANSPARENT, empty if undefined
+  class: string; // PUBLIC, PRIVATE, CONFIDENTIAL, empty if undefined
+  categories?: string[];
+  url: string;
+  priority: number; // 1 (highest) to 9, 0 if undefined
+  organizer?: Attendee;
+  attendees?: Attendee[];
   calendarName: string;
   calendarColor: string;
   isRecurring: boolean;
 }
 
+export interface Attendee {
+  name: string;
+  email: string;
+  role?: string; // CHAIR, REQ-PARTICIPANT, OPT-PARTICIPANT, NON-PARTICIPANT
+  status?: string; // NEEDS-ACTION, ACCEPTED, DECLINED, TENTATIVE, DELEGATED
+}
+
+export interface Task {
+  uid: string;
+  summary: string;
+  description: string;
+  start?: string; // ISO 8601 timestamp
+  due?: string; // ISO 8601 timestamp
+  allDay: boolean;
+  status: string; // NEEDS-ACTION, IN-PROCESS, COMPLETED, CANCELLED
+  class: string; // PUBLIC, PRIVATE, CONFIDENTIAL, empty if undefined
+  percentComplete: number;
+  priority: number; // 1 (highest) to 9, 0 if undefined
+  completed?: string; // ISO 8601 timestamp
+  overdue: boolean; // carried forward to today
+  calendarName: string;
+  calendarColor: string;
+}
+
 export interface Calendar {
   name: string;
   color: string;
@@ -36,6 +68,27 @@ export interface Config {
   timezone: string;
   autoRefresh: number; // seconds
   calendars: Calendar[];
+  auth: string; // none, local, proxy or oidc
+  user?: string; // authenticated user, if authentication is enabled
+  views: string[]; // named subsets of the calendars of the user
+  share?: string; // label of the share link the page was opened with
+}
+
+export interface DateRange {
+  start?: string; // ISO 8601 timestamp, unbounded if missing
+  end?: string; // ISO 8601 timestamp, unbounded if missing
+}
+
+export interface ChangeNotification {
+  calendars: string[];
+  ranges: DateRange[];
+}
+
+// Calendar left out of a response because it could not be fetched
+export interface CalendarWarning {
+  calendar: string;
+  cause: string; // auth, timeout, parse, network or other
+  message: string;
 }
 
 export interface APIError {
@@ -4,4 +4,6 @@ import { svelte } from '@sveltejs/vite-plugin-svelte'
 // https://vite.dev/config/
 export default defineConfig({
   plugins: [svelte()],
+  // Relative asset URLs, so that the UI also loads under share links
+  base: './',
 })